@@ -0,0 +1,50 @@
+package ftpclient
+
+import (
+	"time"
+)
+
+// Telnet interrupt process and data mark bytes.
+const (
+	telnetIP = "\xf4"
+	telnetDM = "\xf2"
+)
+
+// Abort interrupts the transfer in flight and closes the data connection. The
+// interrupt sequence and ABOR are sent on the control connection, and the replies to
+// the transfer command and to ABOR are read, so that the control connection can be
+// used again. Close is a no-op afterwards.
+func (d *FtpDataConn) Abort() error {
+	d.aborting = true
+	return d.Close()
+}
+
+// putAbort sends ABOR preceded by the Telnet IP and Synch sequences, as RFC 959
+// specifies, so that servers busy with a transfer look at the control connection.
+func (c *FtpServerConn) putAbort() error {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	c.command = "ABOR"
+	if err := c.writeSynch(); err != nil {
+		return err
+	}
+	err := c.writeCmd("ABOR")
+	if err == nil {
+		c.pending++
+		c.lastActivity = time.Now()
+	}
+	return err
+}
+
+// writeSynch writes IAC IP followed by the Synch, IAC DM, the way BSD ftp does: the
+// bytes up to the IAC of the Synch are sent as TCP urgent data, so that the urgent
+// mark precedes DM. Over TLS urgent data would bypass the encryption, so everything
+// is sent in band. kaMu must be held.
+func (c *FtpServerConn) writeSynch() error {
+	c.setWriteDeadline(c.conn, c.timeout(c.commandTimeout))
+	if err := writeUrgent(c.conn, []byte(telnetIAC+telnetIP+telnetIAC)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write([]byte(telnetDM))
+	return err
+}
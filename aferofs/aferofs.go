@@ -0,0 +1,373 @@
+// Package aferofs provides an afero.Fs backed by a FtpServerConn, so that
+// applications abstracted over afero can target FTP servers.
+//
+// Files are opened either for reading or for writing. Reads are streamed with RETR
+// and seeking restarts the transfer with REST; writes are streamed with STOR, or
+// APPE when os.O_APPEND is given, and must be sequential. A control connection
+// carries one transfer at a time, so only one file may be open at once and an Fs
+// is not safe for concurrent use.
+package aferofs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/tsujimic/ftpclient-go"
+)
+
+// ErrNotSupported is returned for operations FTP cannot express, such as random
+// writes or reading and writing the same file.
+var ErrNotSupported = errors.New("operation not supported over FTP")
+
+// Fs is an afero.Fs backed by a FtpServerConn.
+type Fs struct {
+	c *ftpclient.FtpServerConn
+}
+
+var _ afero.Fs = (*Fs)(nil)
+
+// New returns an Fs using c. Relative names are resolved against the current remote
+// directory of c.
+func New(c *ftpclient.FtpServerConn) *Fs {
+	return &Fs{c: c}
+}
+
+// Name implements afero.Fs.
+func (f *Fs) Name() string {
+	return "ftp"
+}
+
+// split returns an ftpclient.FS and the fs.FS name under which name is found.
+func (f *Fs) split(name string) (*ftpclient.FS, string) {
+	name = path.Clean(name)
+	if path.IsAbs(name) {
+		rel := strings.TrimPrefix(name, "/")
+		if rel == "" {
+			rel = "."
+		}
+		return ftpclient.NewFS(f.c, "/"), rel
+	}
+	return ftpclient.NewFS(f.c, ""), name
+}
+
+// Create implements afero.Fs.
+func (f *Fs) Create(name string) (afero.File, error) {
+	return f.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// Open implements afero.Fs.
+func (f *Fs) Open(name string) (afero.File, error) {
+	fsys, rel := f.split(name)
+	file, err := fsys.Open(rel)
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return &File{fs: f, name: name, r: file}, nil
+}
+
+// OpenFile implements afero.Fs. Files opened for writing are always created or
+// replaced unless os.O_APPEND is given; os.O_RDWR is only accepted together with
+// os.O_TRUNC or os.O_APPEND, as the file is then written but never read.
+func (f *Fs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return f.Open(name)
+	}
+	if flag&os.O_RDWR != 0 && flag&(os.O_TRUNC|os.O_APPEND) == 0 {
+		return nil, pathError("open", name, ErrNotSupported)
+	}
+
+	var info os.FileInfo
+	if flag&(os.O_EXCL|os.O_APPEND) != 0 || flag&os.O_CREATE == 0 {
+		var err error
+		info, err = f.Stat(name)
+		switch {
+		case err == nil && flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+			return nil, pathError("open", name, os.ErrExist)
+		case err != nil && (flag&os.O_CREATE == 0 || !errors.Is(err, os.ErrNotExist)):
+			return nil, err
+		}
+	}
+
+	var w io.WriteCloser
+	var err error
+	var offset int64
+	if flag&os.O_APPEND != 0 {
+		if info != nil {
+			offset = info.Size()
+		}
+		w, err = f.c.AppeRequest(name)
+	} else {
+		w, err = f.c.StorRequest(name)
+	}
+	if err != nil {
+		return nil, pathError("open", name, err)
+	}
+	return &File{fs: f, name: name, w: w, offset: offset}, nil
+}
+
+// Mkdir implements afero.Fs.
+func (f *Fs) Mkdir(name string, perm os.FileMode) error {
+	if _, err := f.c.Mkd(name); err != nil {
+		if _, serr := f.Stat(name); serr == nil {
+			return pathError("mkdir", name, os.ErrExist)
+		}
+		return pathError("mkdir", name, err)
+	}
+	return nil
+}
+
+// MkdirAll implements afero.Fs.
+func (f *Fs) MkdirAll(name string, perm os.FileMode) error {
+	name = path.Clean(name)
+	if info, err := f.Stat(name); err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return pathError("mkdir", name, errors.New("not a directory"))
+	}
+
+	if parent := path.Dir(name); parent != name {
+		if err := f.MkdirAll(parent, perm); err != nil {
+			return err
+		}
+	}
+	if err := f.Mkdir(name, perm); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+// Remove implements afero.Fs. Directories are removed with RMD and must be empty.
+func (f *Fs) Remove(name string) error {
+	err := f.c.Delete(name)
+	if err == nil {
+		return nil
+	}
+	if rerr := f.c.Rmd(name); rerr == nil {
+		return nil
+	}
+	if _, serr := f.Stat(name); errors.Is(serr, os.ErrNotExist) {
+		return pathError("remove", name, os.ErrNotExist)
+	}
+	return pathError("remove", name, err)
+}
+
+// RemoveAll implements afero.Fs.
+func (f *Fs) RemoveAll(name string) error {
+	if _, err := f.Stat(name); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := f.c.RemoveAll(name); err != nil {
+		return pathError("removeall", name, err)
+	}
+	return nil
+}
+
+// Rename implements afero.Fs.
+func (f *Fs) Rename(oldname, newname string) error {
+	if err := f.c.Rename(oldname, newname); err != nil {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+	return nil
+}
+
+// Stat implements afero.Fs.
+func (f *Fs) Stat(name string) (os.FileInfo, error) {
+	fsys, rel := f.split(name)
+	info, err := fsys.Stat(rel)
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	return info, nil
+}
+
+// Chmod implements afero.Fs with SITE CHMOD.
+func (f *Fs) Chmod(name string, mode os.FileMode) error {
+	if err := f.c.Chmod(name, mode); err != nil {
+		return pathError("chmod", name, err)
+	}
+	return nil
+}
+
+// Chown implements afero.Fs. FTP has no numeric ownership, so it always fails.
+func (f *Fs) Chown(name string, uid, gid int) error {
+	return pathError("chown", name, ErrNotSupported)
+}
+
+// Chtimes implements afero.Fs with MFMT. The access time is ignored.
+func (f *Fs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	_, _, err := f.c.SendCmd(213, "MFMT %s %s", mtime.UTC().Format("20060102150405"), name)
+	if err != nil {
+		return pathError("chtimes", name, err)
+	}
+	return nil
+}
+
+// File is an afero.File opened through Fs, either for reading or for writing.
+type File struct {
+	fs     *Fs
+	name   string
+	r      fs.File
+	w      io.WriteCloser
+	offset int64
+}
+
+var _ afero.File = (*File)(nil)
+
+// Name implements afero.File.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Read implements io.Reader.
+func (f *File) Read(p []byte) (int, error) {
+	if f.r == nil {
+		return 0, pathError("read", f.name, ErrNotSupported)
+	}
+	return f.r.Read(p)
+}
+
+// ReadAt implements io.ReaderAt by seeking to off, which restarts the transfer.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	seeker, ok := f.r.(io.Seeker)
+	if !ok {
+		return 0, pathError("read", f.name, ErrNotSupported)
+	}
+	pos, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = seeker.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	n, err := io.ReadFull(f.r, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	if _, serr := seeker.Seek(pos, io.SeekStart); serr != nil && err == nil {
+		err = serr
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker. Files opened for writing only report their offset.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.w != nil {
+		if offset == 0 && whence == io.SeekCurrent {
+			return f.offset, nil
+		}
+		return 0, pathError("seek", f.name, ErrNotSupported)
+	}
+	seeker, ok := f.r.(io.Seeker)
+	if !ok {
+		return 0, pathError("seek", f.name, ErrNotSupported)
+	}
+	return seeker.Seek(offset, whence)
+}
+
+// Write implements io.Writer.
+func (f *File) Write(p []byte) (int, error) {
+	if f.w == nil {
+		return 0, pathError("write", f.name, ErrNotSupported)
+	}
+	n, err := f.w.Write(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// WriteAt implements io.WriterAt. Only writes at the current offset are supported.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	if off != f.offset {
+		return 0, pathError("write", f.name, ErrNotSupported)
+	}
+	return f.Write(p)
+}
+
+// WriteString implements afero.File.
+func (f *File) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+// Readdir implements afero.File.
+func (f *File) Readdir(count int) ([]os.FileInfo, error) {
+	dir, ok := f.r.(fs.ReadDirFile)
+	if !ok {
+		return nil, pathError("readdir", f.name, errors.New("not a directory"))
+	}
+	entries, err := dir.ReadDir(count)
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, ierr := entry.Info()
+		if ierr != nil {
+			return infos, ierr
+		}
+		infos = append(infos, info)
+	}
+	return infos, err
+}
+
+// Readdirnames implements afero.File.
+func (f *File) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	return names, err
+}
+
+// Stat implements afero.File. A file being written reports the bytes written so far,
+// as the control connection is busy until it is closed.
+func (f *File) Stat() (os.FileInfo, error) {
+	if f.r != nil {
+		return f.r.Stat()
+	}
+	return &writeInfo{name: path.Base(f.name), size: f.offset}, nil
+}
+
+// Sync implements afero.File. Data is flushed to the server as it is written.
+func (f *File) Sync() error {
+	return nil
+}
+
+// Truncate implements afero.File. It is not supported.
+func (f *File) Truncate(size int64) error {
+	return pathError("truncate", f.name, ErrNotSupported)
+}
+
+// Close implements io.Closer and completes the transfer.
+func (f *File) Close() error {
+	if f.r != nil {
+		return f.r.Close()
+	}
+	return f.w.Close()
+}
+
+// writeInfo describes a file while it is being written.
+type writeInfo struct {
+	name string
+	size int64
+}
+
+func (i *writeInfo) Name() string       { return i.name }
+func (i *writeInfo) Size() int64        { return i.size }
+func (i *writeInfo) Mode() os.FileMode  { return 0666 }
+func (i *writeInfo) ModTime() time.Time { return time.Now() }
+func (i *writeInfo) IsDir() bool        { return false }
+func (i *writeInfo) Sys() interface{}   { return nil }
+
+// pathError wraps err in an *os.PathError unless it already is one.
+func pathError(op, name string, err error) error {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return &os.PathError{Op: op, Path: name, Err: pathErr.Err}
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
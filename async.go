@@ -0,0 +1,93 @@
+package ftpclient
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// TransferHandle tracks a file transfer running in the background, started by
+// GetAsync or PutAsync.
+type TransferHandle struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+	bytes  int64 // atomic
+	err    error
+}
+
+// Done returns a channel closed once the transfer has finished.
+func (h *TransferHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Err returns the outcome of the transfer once Done is closed, and nil before.
+func (h *TransferHandle) Err() error {
+	select {
+	case <-h.done:
+		return h.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the transfer has finished and returns its outcome.
+func (h *TransferHandle) Wait() error {
+	<-h.done
+	return h.err
+}
+
+// BytesTransferred returns the number of data bytes moved so far, over all attempts.
+func (h *TransferHandle) BytesTransferred() int64 {
+	return atomic.LoadInt64(&h.bytes)
+}
+
+// Cancel stops the transfer. Done is closed once it has unwound.
+func (h *TransferHandle) Cancel() {
+	h.cancel()
+}
+
+// GetAsync downloads remote into local in the background, as RetrFile does, over a
+// connection cloned from c, so that several transfers can run at once while c stays
+// usable. The clone is closed when the transfer finishes.
+func (c *FtpServerConn) GetAsync(ctx context.Context, remote, local string) *TransferHandle {
+	return c.async(ctx, func(clone *FtpServerConn, ctx context.Context) error {
+		return clone.RetrFileContext(ctx, remote, local)
+	})
+}
+
+// PutAsync uploads local as remote in the background, as StorFile does, over a
+// connection cloned from c. The clone is closed when the transfer finishes.
+func (c *FtpServerConn) PutAsync(ctx context.Context, local, remote string) *TransferHandle {
+	return c.async(ctx, func(clone *FtpServerConn, ctx context.Context) error {
+		return clone.StorFileContext(ctx, local, remote)
+	})
+}
+
+func (c *FtpServerConn) async(ctx context.Context, fn func(clone *FtpServerConn, ctx context.Context) error) *TransferHandle {
+	ctx, cancel := context.WithCancel(ctx)
+	h := &TransferHandle{done: make(chan struct{}), cancel: cancel}
+
+	// Clone reads the working directory of c, so it runs before returning
+	clone, err := c.Clone(ctx)
+	if err != nil {
+		h.err = err
+		cancel()
+		close(h.done)
+		return h
+	}
+	clone.handle = h
+
+	go func() {
+		defer close(h.done)
+		defer cancel()
+		h.err = fn(clone, ctx)
+		clone.Quit()
+	}()
+	return h
+}
+
+// countBytes adds n to the handle of the asynchronous transfer d belongs to, if any.
+func (d *FtpDataConn) countBytes(n int) {
+	if h := d.c.handle; h != nil && !d.listing && n > 0 {
+		atomic.AddInt64(&h.bytes, int64(n))
+	}
+}
@@ -0,0 +1,85 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferHandle(t *testing.T) {
+	// go test -v -run TestTransferHandle
+	addr, fs := memServer(t, map[string]string{"remote.txt": "remote content"}, nil)
+	c := dialTestServer(t, addr, NewConfig())
+
+	dir := t.TempDir()
+	local := filepath.Join(dir, "local.txt")
+	if err := os.WriteFile(local, []byte("local content"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	put := c.PutAsync(context.Background(), local, "uploaded.txt")
+	get := c.GetAsync(context.Background(), "remote.txt", filepath.Join(dir, "downloaded.txt"))
+	for _, h := range []*TransferHandle{put, get} {
+		if err := h.Wait(); err != nil {
+			t.Fatal(err)
+		}
+		<-h.Done()
+		if err := h.Err(); err != nil {
+			t.Errorf("Err() = %v after Wait", err)
+		}
+	}
+
+	if data, _ := fs.file("uploaded.txt"); data != "local content" {
+		t.Errorf("uploaded %q", data)
+	}
+	if n := put.BytesTransferred(); n != int64(len("local content")) {
+		t.Errorf("put transferred %d bytes", n)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "downloaded.txt")); string(data) != "remote content" {
+		t.Errorf("downloaded %q", data)
+	}
+	if n := get.BytesTransferred(); n != int64(len("remote content")) {
+		t.Errorf("get transferred %d bytes", n)
+	}
+
+	// the transfers ran over clones, c stays usable
+	if err := c.Noop(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferHandleCancel(t *testing.T) {
+	// go test -v -run TestTransferHandleCancel
+	addr, _ := memServer(t, nil, func(s *testSession, cmd string) bool {
+		if !strings.HasPrefix(cmd, "RETR ") {
+			return false
+		}
+		s.reply("150 opening")
+		conn, err := s.accept()
+		if err != nil {
+			return true
+		}
+		// never finish the transfer
+		conn.Write([]byte("data"))
+		return true
+	})
+	c := dialTestServer(t, addr, NewConfig())
+
+	h := c.GetAsync(context.Background(), "remote.txt", filepath.Join(t.TempDir(), "local.txt"))
+	if err := h.Err(); err != nil {
+		t.Errorf("Err() = %v while running", err)
+	}
+	h.Cancel()
+	select {
+	case <-h.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("transfer did not unwind after Cancel")
+	}
+	if err := h.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Err() = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,54 @@
+package ftpclient
+
+import (
+	"context"
+)
+
+// Batch collects downloads and uploads and runs them over a bounded pool of
+// connections: c itself and connections cloned from it. Each file is retried
+// according to the RetryPolicy, and uploads are delivered as StorFile does. A Batch
+// is not safe for concurrent use.
+type Batch struct {
+	c           *FtpServerConn
+	concurrency int
+	tasks       []fileTask
+}
+
+// NewBatch returns an empty Batch running up to concurrency transfers in parallel.
+func (c *FtpServerConn) NewBatch(concurrency int) *Batch {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Batch{c: c, concurrency: concurrency}
+}
+
+// Get adds the download of the remote file to the local file.
+func (b *Batch) Get(remote, local string) *Batch {
+	b.tasks = append(b.tasks, fileTask{remote: remote, local: local})
+	return b
+}
+
+// Put adds the upload of the local file to the remote file. The remote file name
+// passes through the configured NameMapper.
+func (b *Batch) Put(local, remote string) *Batch {
+	b.tasks = append(b.tasks, fileTask{remote: remote, local: local, upload: true})
+	return b
+}
+
+// Len returns the number of files in the batch.
+func (b *Batch) Len() int {
+	return len(b.tasks)
+}
+
+// Run transfers the files of the batch. The result reports the outcome of every
+// file in the order they were added; the error joins the failed files. Files not
+// started because ctx is done are reported as skipped.
+func (b *Batch) Run(ctx context.Context) (*BatchResult, error) {
+	result := b.c.runFileTasks(ctx, b.tasks, b.concurrency, func(ctx context.Context, conn *FtpServerConn, t fileTask, item *BatchItem) error {
+		if t.upload {
+			return uploadTask(ctx, conn, t, item)
+		}
+		return downloadTask(ctx, conn, t, item)
+	})
+	return result, result.Err()
+}
@@ -0,0 +1,29 @@
+package ftpclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBatchPutMapsNames(t *testing.T) {
+	addr, fs := memServer(t, nil, nil)
+	c := dialTestServer(t, addr, NewConfig().WithNameMapper(LowerCaseNames))
+
+	local := filepath.Join(t.TempDir(), "Report.CSV")
+	if err := os.WriteFile(local, []byte("data"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := c.NewBatch(1).Put(local, "Out/Report.CSV").Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := fs.file("Out/report.csv"); !ok || data != "data" {
+		t.Errorf("Out/report.csv = %q, %v", data, ok)
+	}
+	if got := result.Items[0].Remote; got != "Out/report.csv" {
+		t.Errorf("item remote = %q, want Out/report.csv", got)
+	}
+}
@@ -0,0 +1,136 @@
+package ftpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of a circuit breaker for one host.
+type CircuitState int
+
+// Circuit breaker states.
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// ErrCircuitOpen is returned when a host is not dialed because its circuit is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker stops connecting to a host after threshold consecutive failures.
+// Once cooldown has elapsed a single half-open probe is let through; the connection
+// is verified with NOOP, which any server reply satisfies, and the circuit closes
+// again when the probe succeeds.
+// A CircuitBreaker is safe for concurrent use and is usually shared through Config.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	hosts     map[string]*circuit
+}
+
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+}
+
+// NewCircuitBreaker ...
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &CircuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*circuit),
+	}
+}
+
+// State returns the circuit state of host.
+func (b *CircuitBreaker) State(host string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if h, ok := b.hosts[host]; ok {
+		return h.state
+	}
+	return CircuitClosed
+}
+
+// Allow returns ErrCircuitOpen when host must not be contacted, and whether the
+// attempt is a half-open probe.
+func (b *CircuitBreaker) Allow(host string) (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.circuit(host)
+	switch h.state {
+	case CircuitOpen:
+		if time.Since(h.openedAt) < b.cooldown {
+			return false, ErrCircuitOpen
+		}
+		h.state = CircuitHalfOpen
+		return true, nil
+	case CircuitHalfOpen:
+		// a probe is already in flight
+		return false, ErrCircuitOpen
+	}
+	return false, nil
+}
+
+// Success records a successful attempt against host and closes its circuit.
+func (b *CircuitBreaker) Success(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.circuit(host)
+	h.state = CircuitClosed
+	h.failures = 0
+}
+
+// Failure records a failed attempt against host and opens its circuit once the
+// threshold is reached or a half-open probe failed.
+func (b *CircuitBreaker) Failure(host string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := b.circuit(host)
+	h.failures++
+	if h.state == CircuitHalfOpen || h.failures >= b.threshold {
+		h.state = CircuitOpen
+		h.openedAt = time.Now()
+	}
+}
+
+// Do runs fn unless the circuit of host is open, and records its outcome.
+func (b *CircuitBreaker) Do(host string, fn func() error) error {
+	if _, err := b.Allow(host); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		b.Failure(host)
+		return err
+	}
+	b.Success(host)
+	return nil
+}
+
+func (b *CircuitBreaker) circuit(host string) *circuit {
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &circuit{}
+		b.hosts[host] = h
+	}
+	return h
+}
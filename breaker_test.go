@@ -0,0 +1,113 @@
+package ftpclient
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	// go test -v -run TestCircuitBreaker
+	cooldown := 20 * time.Millisecond
+	b := NewCircuitBreaker(2, cooldown)
+	host := "ftp.example.com:21"
+
+	state := func(want CircuitState) {
+		t.Helper()
+		if got := b.State(host); got != want {
+			t.Fatalf("State() = %v, want %v", got, want)
+		}
+	}
+
+	state(CircuitClosed)
+	b.Failure(host)
+	state(CircuitClosed)
+	b.Failure(host)
+	state(CircuitOpen)
+	if _, err := b.Allow(host); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v while open", err)
+	}
+	if b.State("other:21") != CircuitClosed {
+		t.Errorf("circuit of another host opened")
+	}
+
+	// a single probe is let through once the cooldown elapsed
+	time.Sleep(cooldown)
+	if probe, err := b.Allow(host); !probe || err != nil {
+		t.Fatalf("Allow() = %v, %v after the cooldown", probe, err)
+	}
+	state(CircuitHalfOpen)
+	if _, err := b.Allow(host); err != ErrCircuitOpen {
+		t.Fatalf("Allow() = %v during the probe", err)
+	}
+
+	// a failed probe opens the circuit again at once
+	b.Failure(host)
+	state(CircuitOpen)
+
+	time.Sleep(cooldown)
+	if probe, err := b.Allow(host); !probe || err != nil {
+		t.Fatalf("Allow() = %v, %v after the second cooldown", probe, err)
+	}
+	b.Success(host)
+	state(CircuitClosed)
+
+	// the failure count starts over once closed
+	b.Failure(host)
+	state(CircuitClosed)
+}
+
+func TestCircuitBreakerDo(t *testing.T) {
+	// go test -v -run TestCircuitBreakerDo
+	b := NewCircuitBreaker(1, time.Hour)
+	failure := errors.New("failure")
+
+	if err := b.Do("host", func() error { return failure }); err != failure {
+		t.Fatalf("Do() = %v", err)
+	}
+	called := false
+	if err := b.Do("host", func() error { called = true; return nil }); err != ErrCircuitOpen || called {
+		t.Errorf("Do() = %v, called %v while open", err, called)
+	}
+	if err := b.Do("other", func() error { return nil }); err != nil {
+		t.Errorf("Do() = %v for another host", err)
+	}
+}
+
+func TestDialProbe(t *testing.T) {
+	// go test -v -run TestDialProbe
+	cases := []struct {
+		Name string
+		Noop string
+		Want CircuitState
+	}{
+		{"noop", "200 ok", CircuitClosed},
+		// servers may refuse NOOP before login
+		{"not logged in", "530 please login with USER and PASS", CircuitClosed},
+		{"malformed reply", "garbage", CircuitOpen},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr := testServer(t, func(s *testSession, cmd string) bool {
+				if cmd != "NOOP" {
+					return false
+				}
+				s.reply(tc.Noop)
+				return true
+			})
+			b := NewCircuitBreaker(1, time.Millisecond)
+			b.Failure(addr)
+			time.Sleep(time.Millisecond)
+
+			c := New(NewConfig().WithCircuitBreaker(b))
+			err := c.Dial(addr)
+			if err == nil {
+				defer c.Quit()
+			}
+			if got := b.State(addr); got != tc.Want {
+				t.Errorf("State() = %v after dial err %v, want %v", got, err, tc.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package ftpclient
+
+import (
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Cursor is a watermark over the entries of a directory, ordered by modification time
+// and then by name. The zero Cursor precedes every entry.
+type Cursor struct {
+	ModTime time.Time
+	Name    string
+}
+
+// after reports whether info comes after the cursor.
+func (cur Cursor) after(info os.FileInfo) bool {
+	mod := info.ModTime()
+	if !mod.Equal(cur.ModTime) {
+		return mod.After(cur.ModTime)
+	}
+	return info.Name() > cur.Name
+}
+
+// String encodes the cursor for persistence between polls, e.g.
+// "20261016T120000Z/data.csv".
+func (cur Cursor) String() string {
+	return cur.ModTime.UTC().Format(cursorLayout) + "/" + cur.Name
+}
+
+const cursorLayout = "20060102T150405.999999999Z"
+
+// ParseCursor decodes a cursor encoded by Cursor.String.
+func ParseCursor(s string) (Cursor, error) {
+	stamp, name, ok := strings.Cut(s, "/")
+	if !ok {
+		return Cursor{}, errors.New("invalid cursor: " + s)
+	}
+	t, err := time.Parse(cursorLayout, stamp)
+	if err != nil {
+		return Cursor{}, err
+	}
+	return Cursor{ModTime: t, Name: name}, nil
+}
+
+// Changes returns the entries of dir modified after the cursor, oldest first, and the
+// cursor to pass to the next call. Pollers thus only see new or modified entries
+// instead of reprocessing the whole directory. MLSD is used when the server supports
+// it, as its modification times are exact; LIST times may lack seconds, so entries
+// modified within the same minute as the cursor can be missed.
+func (c *FtpServerConn) Changes(dir string, cursor Cursor) ([]os.FileInfo, Cursor, error) {
+	infos, err := c.readDir(dir)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	var changed []os.FileInfo
+	for _, info := range infos {
+		if cursor.after(info) {
+			changed = append(changed, info)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool {
+		mi, mj := changed[i].ModTime(), changed[j].ModTime()
+		if !mi.Equal(mj) {
+			return mi.Before(mj)
+		}
+		return changed[i].Name() < changed[j].Name()
+	})
+
+	if len(changed) > 0 {
+		last := changed[len(changed)-1]
+		cursor = Cursor{ModTime: last.ModTime(), Name: last.Name()}
+	}
+	return changed, cursor, nil
+}
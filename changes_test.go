@@ -0,0 +1,22 @@
+package ftpclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursor(t *testing.T) {
+	// go test -v -run TestCursor
+	cur := Cursor{ModTime: time.Date(2026, 10, 16, 12, 0, 0, 500, time.UTC), Name: "2.csv"}
+	parsed, err := ParseCursor(cur.String())
+	if err != nil || !parsed.ModTime.Equal(cur.ModTime) || parsed.Name != cur.Name {
+		t.Errorf("ParseCursor(%q) = %+v, %v", cur.String(), parsed, err)
+	}
+
+	newer := fileInfo{name: "1.csv", mtime: cur.ModTime.Add(time.Second)}
+	same := fileInfo{name: "3.csv", mtime: cur.ModTime}
+	older := fileInfo{name: "9.csv", mtime: cur.ModTime.Add(-time.Second)}
+	if !cur.after(newer) || !cur.after(same) || cur.after(older) {
+		t.Errorf("after() does not order by time then name")
+	}
+}
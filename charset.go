@@ -0,0 +1,150 @@
+package ftpclient
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+)
+
+// Charset is a character set used by a server for pathnames and listing data.
+// The Encoding of UTF-8 is nil, as strings are UTF-8 already.
+type Charset struct {
+	Name     string
+	Encoding encoding.Encoding
+}
+
+// Well known charsets.
+var (
+	UTF8        = Charset{Name: "UTF-8"}
+	ShiftJIS    = Charset{Name: "Shift_JIS", Encoding: japanese.ShiftJIS}
+	EUCJP       = Charset{Name: "EUC-JP", Encoding: japanese.EUCJP}
+	EUCKR       = Charset{Name: "EUC-KR", Encoding: korean.EUCKR}
+	Latin1      = Charset{Name: "ISO-8859-1", Encoding: charmap.ISO8859_1}
+	Windows1252 = Charset{Name: "windows-1252", Encoding: charmap.Windows1252}
+)
+
+// CharsetByName returns the charset with the given IANA name or alias, such as
+// "Shift_JIS", "cp1252" or "euc-kr".
+func CharsetByName(name string) (Charset, error) {
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return Charset{}, err
+	}
+	canonical, err := htmlindex.Name(enc)
+	if err != nil {
+		canonical = name
+	}
+	if strings.EqualFold(canonical, "utf-8") {
+		return UTF8, nil
+	}
+	return Charset{Name: canonical, Encoding: enc}, nil
+}
+
+// DefaultCharsetCandidates are the charsets tried, in order, when sniffing listing data.
+var DefaultCharsetCandidates = []Charset{UTF8, ShiftJIS, Latin1}
+
+// decodes reports whether s is valid text in cs.
+func (cs Charset) decodes(s string) bool {
+	if cs.Encoding == nil {
+		return utf8.ValidString(s)
+	}
+	decoded, err := cs.Encoding.NewDecoder().String(s)
+	return err == nil && strings.Count(decoded, string(utf8.RuneError)) == strings.Count(s, string(utf8.RuneError))
+}
+
+func (cs Charset) decode(s string) string {
+	if cs.Encoding == nil {
+		return s
+	}
+	if decoded, err := cs.Encoding.NewDecoder().String(s); err == nil {
+		return decoded
+	}
+	return s
+}
+
+func (cs Charset) encode(s string) (string, error) {
+	if cs.Encoding == nil {
+		return s, nil
+	}
+	return cs.Encoding.NewEncoder().String(s)
+}
+
+// isASCII reports whether s holds 7-bit characters only, which every candidate decodes alike.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// sniffCharset returns the first candidate that decodes s.
+func sniffCharset(s string, candidates []Charset) (Charset, bool) {
+	for _, cs := range candidates {
+		if cs.decodes(s) {
+			return cs, true
+		}
+	}
+	return Charset{}, false
+}
+
+// Charset returns the charset of the session: the configured one, or the one detected
+// in listing data. It returns false while no charset is known, as long as the server
+// only sent ASCII names.
+func (c *FtpServerConn) Charset() (Charset, bool) {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	return c.sessionCharset()
+}
+
+// sessionCharset is Charset with kaMu held.
+func (c *FtpServerConn) sessionCharset() (Charset, bool) {
+	switch {
+	case c.rfc2640:
+		return UTF8, true
+	case c.charset != nil:
+		return *c.charset, true
+	case c.detectedCharset != nil:
+		return *c.detectedCharset, true
+	}
+	return Charset{}, false
+}
+
+// decodeReply decodes the text of a reply when the charset of the session is known.
+// Replies are not sniffed, as their text is mostly ASCII chosen by the server.
+func (c *FtpServerConn) decodeReply(msg string) string {
+	if isASCII(msg) {
+		return msg
+	}
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	if cs, ok := c.sessionCharset(); ok && !c.rfc2640 {
+		return cs.decode(msg)
+	}
+	return msg
+}
+
+// decodeText decodes a pathname or listing line received from the server. Without a
+// configured charset, the charset is sniffed from the first non-ASCII text received.
+func (c *FtpServerConn) decodeText(s string) string {
+	if isASCII(s) {
+		return s
+	}
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	cs, ok := c.sessionCharset()
+	if !ok {
+		if cs, ok = sniffCharset(s, c.charsetCandidates); !ok {
+			return s
+		}
+		c.detectedCharset = &cs
+		c.infof("detected charset: %s", cs.Name)
+	}
+	return cs.decode(s)
+}
@@ -0,0 +1,43 @@
+package ftpclient
+
+import "testing"
+
+func TestSniffCharset(t *testing.T) {
+	// go test -v -run TestSniffCharset
+	cases := []struct {
+		Text    string
+		Charset string
+		Decoded string
+	}{
+		{"テスト.txt", "UTF-8", "テスト.txt"},
+		{"\x83e\x83X\x83g.txt", "Shift_JIS", "テスト.txt"},
+		{"caf\xe9.txt", "ISO-8859-1", "café.txt"},
+	}
+
+	for _, c := range cases {
+		cs, ok := sniffCharset(c.Text, DefaultCharsetCandidates)
+		if !ok || cs.Name != c.Charset || cs.decode(c.Text) != c.Decoded {
+			t.Errorf("sniffCharset(%q) = %q, %v", c.Text, cs.Name, ok)
+		}
+	}
+}
+
+func TestCharsetByName(t *testing.T) {
+	// go test -v -run TestCharsetByName
+	cases := map[string]string{
+		"Shift_JIS": "shift_jis",
+		"cp1252":    "windows-1252",
+		"euc-kr":    "euc-kr",
+		"utf8":      "UTF-8",
+	}
+
+	for name, want := range cases {
+		cs, err := CharsetByName(name)
+		if err != nil || cs.Name != want {
+			t.Errorf("CharsetByName(%q) = %q, %v", name, cs.Name, err)
+		}
+	}
+	if _, err := CharsetByName("no-such-charset"); err == nil {
+		t.Errorf("CharsetByName(no-such-charset) succeeded")
+	}
+}
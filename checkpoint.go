@@ -0,0 +1,47 @@
+package ftpclient
+
+import "io"
+
+// CheckpointFunc receives the remote path and the absolute byte offset reached by a
+// transfer, suitable for a later REST. Downloads report the bytes written to the
+// destination as they progress. Uploads only report their final offset once the
+// server confirmed the transfer; resume a failed upload from the remote SIZE.
+type CheckpointFunc func(remote string, offset int64)
+
+// checkpointWriter reports checkpoints while data is written through it.
+type checkpointWriter struct {
+	w      io.Writer
+	remote string
+	offset int64
+	next   int64
+	every  int64
+	fn     CheckpointFunc
+}
+
+func (c *FtpServerConn) newCheckpointWriter(w io.Writer, remote string, offset int64) *checkpointWriter {
+	return &checkpointWriter{
+		w:      w,
+		remote: remote,
+		offset: offset,
+		next:   offset + c.checkpointEvery,
+		every:  c.checkpointEvery,
+		fn:     c.checkpoint,
+	}
+}
+
+func (cw *checkpointWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.offset += int64(n)
+	if cw.fn != nil && cw.every > 0 && cw.offset >= cw.next {
+		cw.fn(cw.remote, cw.offset)
+		cw.next = cw.offset + cw.every
+	}
+	return n, err
+}
+
+// done reports the final offset of a completed transfer.
+func (cw *checkpointWriter) done() {
+	if cw.fn != nil {
+		cw.fn(cw.remote, cw.offset)
+	}
+}
@@ -0,0 +1,88 @@
+package ftpclient
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckpointWriter(t *testing.T) {
+	// go test -v -run TestCheckpointWriter
+	var offsets []int64
+	var buf bytes.Buffer
+	c := New(NewConfig().WithCheckpoint(10, func(remote string, offset int64) {
+		if remote != "file" {
+			t.Errorf("checkpoint of %q", remote)
+		}
+		offsets = append(offsets, offset)
+	}))
+
+	// a resumed transfer reports absolute offsets
+	cw := c.newCheckpointWriter(&buf, "file", 5)
+	for _, n := range []int{4, 8, 3, 20, 1} {
+		if _, err := cw.Write(make([]byte, n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cw.done()
+
+	if want := []int64{17, 40, 41}; !reflect.DeepEqual(offsets, want) {
+		t.Errorf("checkpoints = %v, want %v", offsets, want)
+	}
+	if buf.Len() != 36 {
+		t.Errorf("wrote %d bytes, want 36", buf.Len())
+	}
+}
+
+func TestCheckpointWriterDisabled(t *testing.T) {
+	// go test -v -run TestCheckpointWriterDisabled
+	var buf bytes.Buffer
+	cw := New(NewConfig()).newCheckpointWriter(&buf, "file", 0)
+	if n, err := cw.Write([]byte("data")); n != 4 || err != nil {
+		t.Errorf("Write() = %d, %v", n, err)
+	}
+	cw.done()
+}
+
+func TestStorFromCheckpoint(t *testing.T) {
+	// go test -v -run TestStorFromCheckpoint
+	cases := []struct {
+		Name    string
+		Reply   string // final reply to STOR
+		Offsets []int64
+	}{
+		{"stored", "226 stored", []int64{1024}},
+		// the server may have dropped any part of the data
+		{"failed", "451 local error", nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr := testServer(t, func(s *testSession, cmd string) bool {
+				if !strings.HasPrefix(cmd, "STOR ") {
+					return false
+				}
+				s.reply("150 opening")
+				conn, err := s.accept()
+				if err != nil {
+					return true
+				}
+				io.Copy(io.Discard, conn)
+				conn.Close()
+				s.reply(tc.Reply)
+				return true
+			})
+			var offsets []int64
+			c := dialTestServer(t, addr, NewConfig().WithCheckpoint(100, func(remote string, offset int64) {
+				offsets = append(offsets, offset)
+			}))
+
+			c.StorFrom("file", bytes.NewReader(make([]byte, 1024)), 0)
+			if !reflect.DeepEqual(offsets, tc.Offsets) {
+				t.Errorf("checkpoints = %v, want %v", offsets, tc.Offsets)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package ftpclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Chmod issues a SITE CHMOD FTP command, which changes the permissions of path to
+// the permission bits of mode in octal, e.g. 0755. The setuid, setgid and sticky
+// bits are sent as well. It returns an *UnsupportedError when FEAT and SITE HELP
+// show that the server does not implement SITE CHMOD.
+func (c *FtpServerConn) Chmod(path string, mode os.FileMode) error {
+	if err := c.requireSite("CHMOD"); err != nil {
+		return err
+	}
+	_, _, err := c.SendCmd(CommandOkay, "SITE CHMOD %s %s", chmodMode(mode), path)
+	return err
+}
+
+// ChmodContext is like Chmod but honors ctx.
+func (c *FtpServerConn) ChmodContext(ctx context.Context, path string, mode os.FileMode) error {
+	return c.withContext(ctx, func() error {
+		return c.Chmod(path, mode)
+	})
+}
+
+// chmodMode returns mode as the octal argument of SITE CHMOD.
+func chmodMode(mode os.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	if bits > 0777 {
+		return fmt.Sprintf("%04o", bits)
+	}
+	return fmt.Sprintf("%03o", bits)
+}
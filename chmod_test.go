@@ -0,0 +1,31 @@
+package ftpclient
+
+import (
+	"os"
+	"testing"
+)
+
+func TestChmodMode(t *testing.T) {
+	// go test -v -run TestChmodMode
+	cases := []struct {
+		Mode os.FileMode
+		Want string
+	}{
+		{0755, "755"},
+		{0644 | os.ModeDir, "644"},
+		{0600, "600"},
+		{0755 | os.ModeSetuid, "4755"},
+		{0777 | os.ModeSticky | os.ModeSetgid, "3777"},
+	}
+
+	for _, c := range cases {
+		if got := chmodMode(c.Mode); got != c.Want {
+			t.Errorf("chmodMode(%v) = %q, want %q", c.Mode, got, c.Want)
+		}
+	}
+
+	features := parseFeat("Features:\n SITE MKDIR\n SITE CHMOD\nEnd")
+	if params, _ := features.Params("SITE"); params != "MKDIR;CHMOD" {
+		t.Errorf("parseFeat(SITE) = %q", params)
+	}
+}
@@ -0,0 +1,68 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+)
+
+// OwnershipError is returned by Chown and Chgrp when the server refuses to change
+// the owner or group of a file. Err is the reply of the server.
+type OwnershipError struct {
+	Command string
+	Path    string
+	Name    string
+	Err     error
+}
+
+func (e *OwnershipError) Error() string {
+	return e.Command + " " + e.Name + " " + e.Path + ": " + e.Err.Error()
+}
+
+func (e *OwnershipError) Unwrap() error {
+	return e.Err
+}
+
+// Chown issues a SITE CHOWN FTP command, which changes the owner of path to the
+// user named owner. It returns an *UnsupportedError when the server does not
+// implement SITE CHOWN and an *OwnershipError when it refuses the change.
+func (c *FtpServerConn) Chown(path, owner string) error {
+	return c.siteOwnership("CHOWN", path, owner)
+}
+
+// Chgrp issues a SITE CHGRP FTP command, which changes the group of path to the
+// group named group. It returns an *UnsupportedError when the server does not
+// implement SITE CHGRP and an *OwnershipError when it refuses the change.
+func (c *FtpServerConn) Chgrp(path, group string) error {
+	return c.siteOwnership("CHGRP", path, group)
+}
+
+// ChownContext is like Chown but honors ctx.
+func (c *FtpServerConn) ChownContext(ctx context.Context, path, owner string) error {
+	return c.withContext(ctx, func() error {
+		return c.Chown(path, owner)
+	})
+}
+
+// ChgrpContext is like Chgrp but honors ctx.
+func (c *FtpServerConn) ChgrpContext(ctx context.Context, path, group string) error {
+	return c.withContext(ctx, func() error {
+		return c.Chgrp(path, group)
+	})
+}
+
+func (c *FtpServerConn) siteOwnership(command, path, name string) error {
+	if err := c.requireSite(command); err != nil {
+		return err
+	}
+
+	_, _, err := c.SendCmd(CommandOkay, "SITE %s %s %s", command, name, path)
+	if isNotImplemented(err) {
+		return &UnsupportedError{Command: "SITE " + command}
+	}
+	var perr *textproto.Error
+	if errors.As(err, &perr) {
+		return &OwnershipError{Command: "SITE " + command, Path: path, Name: name, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+)
+
+// Clone opens a new control connection to the same server using the same Config,
+// credentials and TLS session cache, and restores the current working directory,
+// transfer type and passive mode of c. The returned connection is independent of c
+// and can be used concurrently with it.
+func (c *FtpServerConn) Clone(ctx context.Context) (*FtpServerConn, error) {
+	if c.addr == "" {
+		return nil, errors.New("clone: connection is not dialed")
+	}
+
+	cwd, err := c.PwdContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := New(c.Config)
+	clone.passive = c.passive
+	// the clone resumes TLS sessions from the session cache of c
+	clone.tlsSession = c.tlsSession
+	if err = clone.dial(ctx, c.addr, 0); err != nil {
+		return nil, err
+	}
+
+	err = clone.withContext(ctx, func() error {
+		return clone.restore(c.user, c.password, cwd, c.transferType)
+	})
+	if err != nil {
+		clone.Quit()
+		return nil, err
+	}
+	return clone, nil
+}
+
+// restore logs in and re-applies the session state of a cloned connection.
+func (c *FtpServerConn) restore(user, password, cwd string, transferType TransferType) error {
+	if err := c.Login(user, password); err != nil {
+		return err
+	}
+
+	if transferType != "" {
+		if err := c.Type(transferType); err != nil {
+			return err
+		}
+	}
+
+	if cwd == "" {
+		return nil
+	}
+	return c.Cwd(cwd)
+}
@@ -0,0 +1,837 @@
+package ftpclient
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"time"
+)
+
+// aLongTimeAgo is a deadline in the past that makes blocked I/O return immediately.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// watch starts interrupting control and data connection I/O once ctx is done.
+// The returned stop function ends the watch and returns ctx.Err() if ctx was done.
+// If ctx is cancelled while a command is in flight the control connection is left
+// in an undefined state and should be closed with Quit. A cancelled data transfer is
+// aborted with ABOR when its data connection is closed, which resynchronizes the
+// control connection.
+func (c *FtpServerConn) watch(ctx context.Context) (stop func() error) {
+	if ctx.Done() == nil {
+		return func() error { return nil }
+	}
+
+	// a nested watch, such as Clone(c.context()), only adds its own cancellation
+	c.ctxMu.Lock()
+	nested := c.ctx != nil
+	if !nested {
+		c.ctx = ctx
+		c.ctxCanceled = ctx.Err() != nil
+		c.ctxConns = nil
+		c.ctxListener = nil
+	}
+	c.ctxMu.Unlock()
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			c.interrupt()
+		case <-quit:
+		}
+	}()
+
+	return func() error {
+		close(quit)
+		<-done
+		if nested {
+			return ctx.Err()
+		}
+
+		c.ctxMu.Lock()
+		defer c.ctxMu.Unlock()
+		canceled := c.ctxCanceled
+		c.ctx = nil
+		c.ctxCanceled = false
+		c.ctxConns = nil
+		c.ctxListener = nil
+		if canceled {
+			return ctx.Err()
+		}
+		return nil
+	}
+}
+
+// withContext runs fn while watching ctx. If ctx is done, its error is returned.
+func (c *FtpServerConn) withContext(ctx context.Context, fn func() error) error {
+	stop := c.watch(ctx)
+	err := fn()
+	if cerr := stop(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// interrupt unblocks every connection taking part in the current operation.
+func (c *FtpServerConn) interrupt() {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	c.ctxCanceled = true
+	if c.conn != nil {
+		c.conn.SetDeadline(aLongTimeAgo)
+	}
+	for _, conn := range c.ctxConns {
+		conn.SetDeadline(aLongTimeAgo)
+	}
+	if c.ctxListener != nil {
+		c.ctxListener.Close()
+	}
+}
+
+// canceled reports whether the context of the current operation is done.
+func (c *FtpServerConn) canceled() bool {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	return c.ctxCanceled
+}
+
+// resync runs fn, an exchange bringing the control connection back in step, with the
+// cancellation of the current operation lifted from the control connection.
+func (c *FtpServerConn) resync(fn func() error) error {
+	c.ctxMu.Lock()
+	c.ctxCanceled = false
+	c.conn.SetDeadline(time.Time{})
+	c.ctxMu.Unlock()
+
+	err := fn()
+
+	c.ctxMu.Lock()
+	c.ctxCanceled = true
+	c.conn.SetDeadline(aLongTimeAgo)
+	c.ctxMu.Unlock()
+	return err
+}
+
+// context returns the context of the current operation.
+func (c *FtpServerConn) context() context.Context {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// setConn replaces the control connection. Both kaMu and ctxMu are held, so that
+// neither the keepalive nor the watch of the current operation sees the connection
+// while it is replaced.
+func (c *FtpServerConn) setConn(conn net.Conn) {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	c.conn = conn
+	c.textprotoConn = textproto.NewConn(conn)
+	if c.ctxCanceled {
+		conn.SetDeadline(aLongTimeAgo)
+	}
+}
+
+// trackConn registers a data connection with the current operation.
+func (c *FtpServerConn) trackConn(conn net.Conn) {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	if c.ctx == nil {
+		return
+	}
+	c.ctxConns = append(c.ctxConns, conn)
+	if c.ctxCanceled {
+		conn.SetDeadline(aLongTimeAgo)
+	}
+}
+
+// trackListener registers an active mode listener with the current operation.
+func (c *FtpServerConn) trackListener(listener net.Listener) {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	if c.ctx == nil {
+		return
+	}
+	c.ctxListener = listener
+	if c.ctxCanceled {
+		listener.Close()
+	}
+}
+
+// setReadDeadline sets the read deadline of conn unless the current operation was cancelled.
+func (c *FtpServerConn) setReadDeadline(conn net.Conn, timeout time.Duration) {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	if c.ctxCanceled {
+		conn.SetReadDeadline(aLongTimeAgo)
+		return
+	}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+}
+
+// setWriteDeadline sets the write deadline of conn unless the current operation was cancelled.
+func (c *FtpServerConn) setWriteDeadline(conn net.Conn, timeout time.Duration) {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	if c.ctxCanceled {
+		conn.SetWriteDeadline(aLongTimeAgo)
+		return
+	}
+	conn.SetWriteDeadline(time.Now().Add(timeout))
+}
+
+// withDataContext attaches the watch of ctx to the data connection returned by a
+// request method, so that ctx keeps interrupting it until it is closed.
+func (c *FtpServerConn) withDataContext(ctx context.Context, fn func() (*FtpDataConn, error)) (*FtpDataConn, error) {
+	stop := c.watch(ctx)
+	d, err := fn()
+	if err != nil {
+		if cerr := stop(); cerr != nil {
+			return nil, cerr
+		}
+		return nil, err
+	}
+	d.stop = stop
+	return d, nil
+}
+
+// DialContext connects to the FTP server at addr using ctx.
+func (c *FtpServerConn) DialContext(ctx context.Context, addr string) error {
+	return c.dial(ctx, addr, 0)
+}
+
+// LoginContext is like Login but honors ctx.
+func (c *FtpServerConn) LoginContext(ctx context.Context, user, password string) error {
+	return c.withContext(ctx, func() error {
+		return c.Login(user, password)
+	})
+}
+
+// LoginAnonymousContext is like LoginAnonymous but honors ctx.
+func (c *FtpServerConn) LoginAnonymousContext(ctx context.Context, email string) error {
+	return c.withContext(ctx, func() error {
+		return c.LoginAnonymous(email)
+	})
+}
+
+// TypeContext is like Type but honors ctx.
+func (c *FtpServerConn) TypeContext(ctx context.Context, param TransferType) error {
+	return c.withContext(ctx, func() error {
+		return c.Type(param)
+	})
+}
+
+// CwdContext is like Cwd but honors ctx.
+func (c *FtpServerConn) CwdContext(ctx context.Context, path string) error {
+	return c.withContext(ctx, func() error {
+		return c.Cwd(path)
+	})
+}
+
+// CdupContext is like Cdup but honors ctx.
+func (c *FtpServerConn) CdupContext(ctx context.Context) error {
+	return c.withContext(ctx, c.Cdup)
+}
+
+// PwdContext is like Pwd but honors ctx.
+func (c *FtpServerConn) PwdContext(ctx context.Context) (dir string, err error) {
+	err = c.withContext(ctx, func() error {
+		dir, err = c.Pwd()
+		return err
+	})
+	return dir, err
+}
+
+// RenameContext is like Rename but honors ctx.
+func (c *FtpServerConn) RenameContext(ctx context.Context, from, to string) error {
+	return c.withContext(ctx, func() error {
+		return c.Rename(from, to)
+	})
+}
+
+// DeleteContext is like Delete but honors ctx.
+func (c *FtpServerConn) DeleteContext(ctx context.Context, path string) error {
+	return c.withContext(ctx, func() error {
+		return c.Delete(path)
+	})
+}
+
+// MkdContext is like Mkd but honors ctx.
+func (c *FtpServerConn) MkdContext(ctx context.Context, path string) (dir string, err error) {
+	err = c.withContext(ctx, func() error {
+		dir, err = c.Mkd(path)
+		return err
+	})
+	return dir, err
+}
+
+// RmdContext is like Rmd but honors ctx.
+func (c *FtpServerConn) RmdContext(ctx context.Context, path string) error {
+	return c.withContext(ctx, func() error {
+		return c.Rmd(path)
+	})
+}
+
+// NoopContext is like Noop but honors ctx.
+func (c *FtpServerConn) NoopContext(ctx context.Context) error {
+	return c.withContext(ctx, c.Noop)
+}
+
+// RestContext is like Rest but honors ctx.
+func (c *FtpServerConn) RestContext(ctx context.Context, offset uint64) error {
+	return c.withContext(ctx, func() error {
+		return c.Rest(offset)
+	})
+}
+
+// ReinContext is like Rein but honors ctx.
+func (c *FtpServerConn) ReinContext(ctx context.Context) error {
+	return c.withContext(ctx, c.Rein)
+}
+
+// AbortContext is like Abort but honors ctx.
+func (c *FtpServerConn) AbortContext(ctx context.Context) error {
+	return c.withContext(ctx, c.Abort)
+}
+
+// SystContext is like Syst but honors ctx.
+func (c *FtpServerConn) SystContext(ctx context.Context) (syst string, err error) {
+	err = c.withContext(ctx, func() error {
+		syst, err = c.Syst()
+		return err
+	})
+	return syst, err
+}
+
+// QuitContext is like Quit but honors ctx. The connection is closed in any case.
+func (c *FtpServerConn) QuitContext(ctx context.Context) error {
+	return c.withContext(ctx, c.Quit)
+}
+
+// SizeContext is like Size but honors ctx.
+func (c *FtpServerConn) SizeContext(ctx context.Context, filename string) (size int, err error) {
+	err = c.withContext(ctx, func() error {
+		size, err = c.Size(filename)
+		return err
+	})
+	return size, err
+}
+
+// ModTimeContext is like ModTime but honors ctx.
+func (c *FtpServerConn) ModTimeContext(ctx context.Context, filename string) (mtime time.Time, err error) {
+	err = c.withContext(ctx, func() error {
+		mtime, err = c.ModTime(filename)
+		return err
+	})
+	return mtime, err
+}
+
+// NlstRequestContext is like NlstRequest but honors ctx until the returned ReadCloser is closed.
+func (c *FtpServerConn) NlstRequestContext(ctx context.Context, args ...string) (io.ReadCloser, error) {
+	return c.dataRequestContext(ctx, func() (io.ReadCloser, error) {
+		return c.NlstRequest(args...)
+	})
+}
+
+// ListRequestContext is like ListRequest but honors ctx until the returned ReadCloser is closed.
+func (c *FtpServerConn) ListRequestContext(ctx context.Context, args ...string) (io.ReadCloser, error) {
+	return c.dataRequestContext(ctx, func() (io.ReadCloser, error) {
+		return c.ListRequest(args...)
+	})
+}
+
+// RetrRequestContext is like RetrRequest but honors ctx until the returned ReadCloser is closed.
+func (c *FtpServerConn) RetrRequestContext(ctx context.Context, path string) (io.ReadCloser, error) {
+	return c.dataRequestContext(ctx, func() (io.ReadCloser, error) {
+		return c.RetrRequest(path)
+	})
+}
+
+// RetrRequestFromContext is like RetrRequestFrom but honors ctx until the returned ReadCloser is closed.
+func (c *FtpServerConn) RetrRequestFromContext(ctx context.Context, path string, offset uint64) (io.ReadCloser, error) {
+	return c.dataRequestContext(ctx, func() (io.ReadCloser, error) {
+		return c.RetrRequestFrom(path, offset)
+	})
+}
+
+// StorRequestContext is like StorRequest but honors ctx until the returned WriteCloser is closed.
+func (c *FtpServerConn) StorRequestContext(ctx context.Context, path string) (io.WriteCloser, error) {
+	d, err := c.withDataContext(ctx, func() (*FtpDataConn, error) {
+		w, err := c.StorRequest(path)
+		if err != nil {
+			return nil, err
+		}
+		return w.(*FtpDataConn), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// StorAtContext is like StorAt but honors ctx until the returned WriteCloser is closed.
+func (c *FtpServerConn) StorAtContext(ctx context.Context, path string, offset int64) (io.WriteCloser, error) {
+	d, err := c.withDataContext(ctx, func() (*FtpDataConn, error) {
+		w, err := c.StorAt(path, offset)
+		if err != nil {
+			return nil, err
+		}
+		return w.(*FtpDataConn), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// AppeRequestContext is like AppeRequest but honors ctx until the returned WriteCloser is closed.
+func (c *FtpServerConn) AppeRequestContext(ctx context.Context, path string) (io.WriteCloser, error) {
+	d, err := c.withDataContext(ctx, func() (*FtpDataConn, error) {
+		w, err := c.AppeRequest(path)
+		if err != nil {
+			return nil, err
+		}
+		return w.(*FtpDataConn), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// StouRequestContext is like StouRequest but honors ctx until the returned StouWriter is closed.
+func (c *FtpServerConn) StouRequestContext(ctx context.Context) (*StouWriter, string, error) {
+	var w *StouWriter
+	var name string
+	_, err := c.withDataContext(ctx, func() (*FtpDataConn, error) {
+		var err error
+		w, name, err = c.StouRequest()
+		if err != nil {
+			return nil, err
+		}
+		return w.FtpDataConn, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return w, name, nil
+}
+
+// TransferRequestContext is like TransferRequest but honors ctx until the returned ReadCloser is closed.
+func (c *FtpServerConn) TransferRequestContext(ctx context.Context, format string, args ...interface{}) (io.ReadCloser, error) {
+	return c.dataRequestContext(ctx, func() (io.ReadCloser, error) {
+		return c.TransferRequest(format, args...)
+	})
+}
+
+// dataRequestContext runs a request method returning a reader under ctx.
+func (c *FtpServerConn) dataRequestContext(ctx context.Context, fn func() (io.ReadCloser, error)) (io.ReadCloser, error) {
+	d, err := c.withDataContext(ctx, func() (*FtpDataConn, error) {
+		r, err := fn()
+		if err != nil {
+			return nil, err
+		}
+		return r.(*FtpDataConn), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// NlstContext is like Nlst but honors ctx.
+func (c *FtpServerConn) NlstContext(ctx context.Context, args ...string) (lines []string, err error) {
+	err = c.withContext(ctx, func() error {
+		lines, err = c.Nlst(args...)
+		return err
+	})
+	return lines, err
+}
+
+// ListContext is like List but honors ctx.
+func (c *FtpServerConn) ListContext(ctx context.Context, args ...string) (lines []string, err error) {
+	err = c.withContext(ctx, func() error {
+		lines, err = c.List(args...)
+		return err
+	})
+	return lines, err
+}
+
+// DirContext is like Dir but honors ctx.
+func (c *FtpServerConn) DirContext(ctx context.Context, args ...string) (infos []os.FileInfo, err error) {
+	err = c.withContext(ctx, func() error {
+		infos, err = c.Dir(args...)
+		return err
+	})
+	return infos, err
+}
+
+// RetrContext is like Retr but honors ctx.
+func (c *FtpServerConn) RetrContext(ctx context.Context, path string) error {
+	return c.withContext(ctx, func() error {
+		return c.Retr(path)
+	})
+}
+
+// StorContext is like Stor but honors ctx.
+func (c *FtpServerConn) StorContext(ctx context.Context, path string) error {
+	return c.withContext(ctx, func() error {
+		return c.Stor(path)
+	})
+}
+
+// AppeContext is like Appe but honors ctx.
+func (c *FtpServerConn) AppeContext(ctx context.Context, path string) error {
+	return c.withContext(ctx, func() error {
+		return c.Appe(path)
+	})
+}
+
+// RetrFileContext is like RetrFile but honors ctx.
+func (c *FtpServerConn) RetrFileContext(ctx context.Context, remote, local string) error {
+	return c.transferContext(ctx, "RETR", remote, local, func() error {
+		return c.RetrFile(remote, local)
+	})
+}
+
+// StorFileContext is like StorFile but honors ctx.
+func (c *FtpServerConn) StorFileContext(ctx context.Context, local, remote string) error {
+	return c.transferContext(ctx, "STOR", local, remote, func() error {
+		return c.StorFile(local, remote)
+	})
+}
+
+// AppendFileContext is like AppendFile but honors ctx.
+func (c *FtpServerConn) AppendFileContext(ctx context.Context, local, remote string) error {
+	return c.transferContext(ctx, "APPE", local, remote, func() error {
+		return c.AppendFile(local, remote)
+	})
+}
+
+// ResumeRetrFileContext is like ResumeRetrFile but honors ctx.
+func (c *FtpServerConn) ResumeRetrFileContext(ctx context.Context, remote, local string) error {
+	return c.withContext(ctx, func() error {
+		return c.ResumeRetrFile(remote, local)
+	})
+}
+
+// RetrToContext is like RetrTo but honors ctx.
+func (c *FtpServerConn) RetrToContext(ctx context.Context, remote string, w io.Writer, offset uint64) (n int64, err error) {
+	err = c.withContext(ctx, func() error {
+		n, err = c.RetrTo(remote, w, offset)
+		return err
+	})
+	return n, err
+}
+
+// StorFromContext is like StorFrom but honors ctx.
+func (c *FtpServerConn) StorFromContext(ctx context.Context, remote string, r io.Reader, offset uint64) (n int64, err error) {
+	err = c.withContext(ctx, func() error {
+		n, err = c.StorFrom(remote, r, offset)
+		return err
+	})
+	return n, err
+}
+
+// SendCmdContext is like SendCmd but honors ctx.
+func (c *FtpServerConn) SendCmdContext(ctx context.Context, expectCode int, format string, args ...interface{}) (code int, msg string, err error) {
+	err = c.withContext(ctx, func() error {
+		code, msg, err = c.SendCmd(expectCode, format, args...)
+		return err
+	})
+	return code, msg, err
+}
+
+// PasvContext is like Pasv but honors ctx.
+func (c *FtpServerConn) PasvContext(ctx context.Context) (host string, port int, err error) {
+	err = c.withContext(ctx, func() error {
+		host, port, err = c.Pasv()
+		return err
+	})
+	return host, port, err
+}
+
+// CpsvContext is like Cpsv but honors ctx.
+func (c *FtpServerConn) CpsvContext(ctx context.Context) (host string, port int, err error) {
+	err = c.withContext(ctx, func() error {
+		host, port, err = c.Cpsv()
+		return err
+	})
+	return host, port, err
+}
+
+// EpsvContext is like Epsv but honors ctx.
+func (c *FtpServerConn) EpsvContext(ctx context.Context) (port int, err error) {
+	err = c.withContext(ctx, func() error {
+		port, err = c.Epsv()
+		return err
+	})
+	return port, err
+}
+
+// EpsvAllContext is like EpsvAll but honors ctx.
+func (c *FtpServerConn) EpsvAllContext(ctx context.Context) error {
+	return c.withContext(ctx, c.EpsvAll)
+}
+
+// PortContext is like Port but honors ctx.
+func (c *FtpServerConn) PortContext(ctx context.Context, host string, port int) error {
+	return c.withContext(ctx, func() error {
+		return c.Port(host, port)
+	})
+}
+
+// EprtContext is like Eprt but honors ctx.
+func (c *FtpServerConn) EprtContext(ctx context.Context, host string, port int) error {
+	return c.withContext(ctx, func() error {
+		return c.Eprt(host, port)
+	})
+}
+
+// AuthContext is like Auth but honors ctx.
+func (c *FtpServerConn) AuthContext(ctx context.Context, param string) error {
+	return c.withContext(ctx, func() error {
+		return c.Auth(param)
+	})
+}
+
+// PbszContext is like Pbsz but honors ctx.
+func (c *FtpServerConn) PbszContext(ctx context.Context, param string) error {
+	return c.withContext(ctx, func() error {
+		return c.Pbsz(param)
+	})
+}
+
+// ProtContext is like Prot but honors ctx.
+func (c *FtpServerConn) ProtContext(ctx context.Context, param string) error {
+	return c.withContext(ctx, func() error {
+		return c.Prot(param)
+	})
+}
+
+// FeatContext is like Feat but honors ctx.
+func (c *FtpServerConn) FeatContext(ctx context.Context) (features Features, err error) {
+	err = c.withContext(ctx, func() error {
+		features, err = c.Feat()
+		return err
+	})
+	return features, err
+}
+
+// OptsContext is like Opts but honors ctx.
+func (c *FtpServerConn) OptsContext(ctx context.Context, param string) error {
+	return c.withContext(ctx, func() error {
+		return c.Opts(param)
+	})
+}
+
+// GetResponseContext is like GetResponse but honors ctx.
+func (c *FtpServerConn) GetResponseContext(ctx context.Context, expectCode int, timeout time.Duration) (code int, msg string, err error) {
+	err = c.withContext(ctx, func() error {
+		code, msg, err = c.GetResponse(expectCode, timeout)
+		return err
+	})
+	return code, msg, err
+}
+
+// WithTransactionalRenameContext is like WithTransactionalRename but honors ctx.
+func (c *FtpServerConn) WithTransactionalRenameContext(ctx context.Context, uploads []TransactionalUpload) error {
+	return c.withContext(ctx, func() error {
+		return c.WithTransactionalRename(uploads)
+	})
+}
+
+// MlsdContext is like Mlsd but honors ctx.
+func (c *FtpServerConn) MlsdContext(ctx context.Context, path ...string) (entries []*MlsEntry, err error) {
+	err = c.withContext(ctx, func() error {
+		entries, err = c.Mlsd(path...)
+		return err
+	})
+	return entries, err
+}
+
+// MlstContext is like Mlst but honors ctx.
+func (c *FtpServerConn) MlstContext(ctx context.Context, path string) (entry *MlsEntry, err error) {
+	err = c.withContext(ctx, func() error {
+		entry, err = c.Mlst(path)
+		return err
+	})
+	return entry, err
+}
+
+// SiteHelpContext is like SiteHelp but honors ctx.
+func (c *FtpServerConn) SiteHelpContext(ctx context.Context) (commands map[string]bool, err error) {
+	err = c.withContext(ctx, func() error {
+		commands, err = c.SiteHelp()
+		return err
+	})
+	return commands, err
+}
+
+// SiteContext is like Site but honors ctx.
+func (c *FtpServerConn) SiteContext(ctx context.Context, args ...string) (code int, lines []string, err error) {
+	err = c.withContext(ctx, func() error {
+		code, lines, err = c.Site(args...)
+		return err
+	})
+	return code, lines, err
+}
+
+// WalkContext is like Walk but honors ctx.
+func (c *FtpServerConn) WalkContext(ctx context.Context, root string, fn WalkFunc) error {
+	return c.withContext(ctx, func() error {
+		return c.Walk(root, fn)
+	})
+}
+
+// ManifestContext is like Manifest but honors ctx.
+func (c *FtpServerConn) ManifestContext(ctx context.Context, dir, algo string) (entries []ManifestEntry, err error) {
+	err = c.withContext(ctx, func() error {
+		entries, err = c.Manifest(dir, algo)
+		return err
+	})
+	return entries, err
+}
+
+// NameStreamContext is like NameStream but honors ctx.
+func (c *FtpServerConn) NameStreamContext(ctx context.Context, dir string, fn func(name string) error) error {
+	return c.withContext(ctx, func() error {
+		return c.NameStream(dir, fn)
+	})
+}
+
+// NameCountContext is like NameCount but honors ctx.
+func (c *FtpServerConn) NameCountContext(ctx context.Context, dir string) (n int, err error) {
+	err = c.withContext(ctx, func() error {
+		n, err = c.NameCount(dir)
+		return err
+	})
+	return n, err
+}
+
+// RemoveAllContext is like RemoveAll but honors ctx.
+func (c *FtpServerConn) RemoveAllContext(ctx context.Context, name string) error {
+	return c.withContext(ctx, func() error {
+		return c.RemoveAll(name)
+	})
+}
+
+// ListHereContext is like ListHere but honors ctx.
+func (c *FtpServerConn) ListHereContext(ctx context.Context) (infos []os.FileInfo, err error) {
+	err = c.withContext(ctx, func() error {
+		infos, err = c.ListHere()
+		return err
+	})
+	return infos, err
+}
+
+// GetHereContext is like GetHere but honors ctx.
+func (c *FtpServerConn) GetHereContext(ctx context.Context, name, local string) error {
+	return c.withContext(ctx, func() error {
+		return c.GetHere(name, local)
+	})
+}
+
+// PutHereContext is like PutHere but honors ctx.
+func (c *FtpServerConn) PutHereContext(ctx context.Context, local, name string) error {
+	return c.withContext(ctx, func() error {
+		return c.PutHere(local, name)
+	})
+}
+
+// RetrFileStatsContext is like RetrFileStats but honors ctx.
+func (c *FtpServerConn) RetrFileStatsContext(ctx context.Context, remote, local string) (stats TransferStats, err error) {
+	err = c.transferContext(ctx, "RETR", remote, local, func() error {
+		stats, err = c.RetrFileStats(remote, local)
+		return err
+	})
+	return stats, err
+}
+
+// StorFileStatsContext is like StorFileStats but honors ctx.
+func (c *FtpServerConn) StorFileStatsContext(ctx context.Context, local, remote string) (stats TransferStats, err error) {
+	err = c.transferContext(ctx, "STOR", local, remote, func() error {
+		stats, err = c.StorFileStats(local, remote)
+		return err
+	})
+	return stats, err
+}
+
+// ModeContext is like Mode but honors ctx.
+func (c *FtpServerConn) ModeContext(ctx context.Context, mode TransferMode) error {
+	return c.withContext(ctx, func() error {
+		return c.Mode(mode)
+	})
+}
+
+// StruContext is like Stru but honors ctx.
+func (c *FtpServerConn) StruContext(ctx context.Context, stru FileStructure) error {
+	return c.withContext(ctx, func() error {
+		return c.Stru(stru)
+	})
+}
+
+// StorDatasetContext is like StorDataset but honors ctx.
+func (c *FtpServerConn) StorDatasetContext(ctx context.Context, local, dataset string, attrs DatasetAttrs) error {
+	return c.withContext(ctx, func() error {
+		return c.StorDataset(local, dataset, attrs)
+	})
+}
+
+// HostContext is like Host but honors ctx.
+func (c *FtpServerConn) HostContext(ctx context.Context, name string) error {
+	return c.withContext(ctx, func() error {
+		return c.Host(name)
+	})
+}
+
+// LangContext is like Lang but honors ctx.
+func (c *FtpServerConn) LangContext(ctx context.Context, tag string) error {
+	return c.withContext(ctx, func() error {
+		return c.Lang(tag)
+	})
+}
+
+// HashContext is like Hash but honors ctx.
+func (c *FtpServerConn) HashContext(ctx context.Context, path string) (result *HashResult, err error) {
+	err = c.withContext(ctx, func() error {
+		result, err = c.Hash(path)
+		return err
+	})
+	return result, err
+}
+
+// ChangesContext is like Changes but honors ctx.
+func (c *FtpServerConn) ChangesContext(ctx context.Context, dir string, cursor Cursor) (infos []os.FileInfo, next Cursor, err error) {
+	next = cursor
+	err = c.withContext(ctx, func() error {
+		infos, next, err = c.Changes(dir, cursor)
+		return err
+	})
+	return infos, next, err
+}
+
+// StorFileVerifiedContext is like StorFileVerified but honors ctx.
+func (c *FtpServerConn) StorFileVerifiedContext(ctx context.Context, local, remote string) error {
+	return c.transferContext(ctx, "STOR", local, remote, func() error {
+		return c.StorFileVerified(local, remote)
+	})
+}
+
+// SendCmdLinesContext is like SendCmdLines but honors ctx.
+func (c *FtpServerConn) SendCmdLinesContext(ctx context.Context, expectCode int, format string, args ...interface{}) (code int, lines []string, err error) {
+	err = c.withContext(ctx, func() error {
+		code, lines, err = c.SendCmdLines(expectCode, format, args...)
+		return err
+	})
+	return code, lines, err
+}
@@ -0,0 +1,62 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestContextCancelTransfer(t *testing.T) {
+	// go test -v -run TestContextCancelTransfer
+	var data net.Conn
+	var aborted int32
+	addr, _ := memServer(t, nil, func(s *testSession, cmd string) bool {
+		switch {
+		case strings.HasPrefix(cmd, "RETR "):
+			s.reply("150 opening")
+			conn, err := s.accept()
+			if err != nil {
+				return true
+			}
+			// send a little and stall with the connection open
+			conn.Write([]byte("data"))
+			data = conn
+		case strings.HasSuffix(cmd, "ABOR"):
+			atomic.StoreInt32(&aborted, 1)
+			if data != nil {
+				data.Close()
+			}
+			s.reply("426 transfer aborted")
+			s.reply("226 abort successful")
+		case cmd == "NOOP":
+			s.reply("200 noop")
+		default:
+			return false
+		}
+		return true
+	})
+	c := dialTestServer(t, addr, NewConfig())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.RetrToContext(ctx, "file", io.Discard, 0)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if atomic.LoadInt32(&aborted) == 0 {
+		t.Error("transfer was not aborted")
+	}
+
+	// both ABOR replies were consumed, the next reply is that of NOOP
+	if err = c.Noop(); err != nil {
+		t.Fatal(err)
+	}
+	if code, msg := c.LastResponse(); code != 200 || msg != "noop" {
+		t.Errorf("last response = %d %s, want 200 noop", code, msg)
+	}
+}
@@ -0,0 +1,67 @@
+package ftpclient
+
+import (
+	"context"
+	"io"
+)
+
+// Copy duplicates the remote file src as dst. It uses the SITE CPFR/CPTO commands of
+// ProFTPD mod_copy when the server supports them, and otherwise streams the file
+// through the client over a second control connection opened with Clone.
+func (c *FtpServerConn) Copy(src, dst string) error {
+	if c.requireSite("CPFR") == nil {
+		err := c.siteCopy(src, dst)
+		if !isNotImplemented(err) {
+			return err
+		}
+	}
+	return c.streamCopy(src, dst)
+}
+
+// siteCopy copies src to dst on the server with SITE CPFR and SITE CPTO.
+func (c *FtpServerConn) siteCopy(src, dst string) error {
+	if _, _, err := c.SendCmd(ActionPending, "SITE CPFR %s", src); err != nil {
+		return err
+	}
+	_, _, err := c.SendCmd(ActionOK, "SITE CPTO %s", dst)
+	return err
+}
+
+// streamCopy copies src to dst by downloading on c and uploading on a clone of c.
+func (c *FtpServerConn) streamCopy(src, dst string) (err error) {
+	dest, err := c.Clone(c.context())
+	if err != nil {
+		return err
+	}
+	defer dest.Quit()
+
+	reader, err := c.RetrRequest(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := reader.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	writer, err := dest.StorRequest(dst)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	_, err = io.CopyBuffer(writer, reader, make([]byte, c.bufferSize))
+	return err
+}
+
+// CopyContext is like Copy but honors ctx.
+func (c *FtpServerConn) CopyContext(ctx context.Context, src, dst string) error {
+	return c.withContext(ctx, func() error {
+		return c.Copy(src, dst)
+	})
+}
@@ -0,0 +1,84 @@
+package ftpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// Dialer dials the control connection and passive data connections. *net.Dialer
+// implements it, as do proxy dialers; a custom Dialer may also route connections
+// elsewhere, such as to a Unix socket in tests.
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// dialConn connects to address with the configured Dialer, or with a net.Dialer bound
+// to the configured local address. A positive timeout bounds the dial.
+func (c *FtpServerConn) dialConn(ctx context.Context, address string, timeout time.Duration) (net.Conn, error) {
+	if c.dialer == nil {
+		dialer := &net.Dialer{
+			Timeout: timeout,
+		}
+		if c.localAddr != "" {
+			ip, err := c.localIP()
+			if err != nil {
+				return nil, err
+			}
+			dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return c.dialer.DialContext(ctx, network, address)
+}
+
+// controlHost returns the host of the control connection peer, or the dialed host when
+// a custom Dialer returned a connection without a network address, e.g. a Unix socket.
+func (c *FtpServerConn) controlHost() (string, error) {
+	host, _, err := net.SplitHostPort(c.conn.RemoteAddr().String())
+	if err != nil && c.dialer != nil {
+		host, _, err = net.SplitHostPort(c.addr)
+	}
+	return host, err
+}
+
+// localIP resolves the configured local address, either an IP address or the name of
+// a network interface, whose first IPv4 address is preferred.
+func (c *Config) localIP() (net.IP, error) {
+	if ip := net.ParseIP(c.localAddr); ip != nil {
+		return ip, nil
+	}
+
+	iface, err := net.InterfaceByName(c.localAddr)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var ip net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ipnet.IP.To4() != nil {
+			return ipnet.IP, nil
+		}
+		if ip == nil {
+			ip = ipnet.IP
+		}
+	}
+	if ip == nil {
+		return nil, fmt.Errorf("interface %s has no IP address", c.localAddr)
+	}
+	return ip, nil
+}
@@ -0,0 +1,146 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tsujimic/ftpclient-go/replycode"
+)
+
+// DualMode is the order in which a DualUploader stores files.
+type DualMode int
+
+// Dual upload modes.
+const (
+	// DualSequential uploads to the primary first, and to the standby once it succeeded.
+	DualSequential DualMode = iota
+	// DualConcurrent uploads to both servers at once.
+	DualConcurrent
+)
+
+// DualUploadError is returned when a dual upload failed on either server.
+// RollbackErr is set when the copy stored on the other server could not be removed.
+type DualUploadError struct {
+	Primary     error
+	Standby     error
+	RollbackErr error
+}
+
+func (e *DualUploadError) Error() string {
+	msg := "dual upload failed:"
+	if e.Primary != nil {
+		msg += fmt.Sprintf(" primary: %v;", e.Primary)
+	}
+	if e.Standby != nil {
+		msg += fmt.Sprintf(" standby: %v;", e.Standby)
+	}
+	if e.RollbackErr != nil {
+		msg += fmt.Sprintf(" rollback: %v;", e.RollbackErr)
+	}
+	return msg[:len(msg)-1]
+}
+
+func (e *DualUploadError) Unwrap() []error {
+	var errs []error
+	for _, err := range []error{e.Primary, e.Standby, e.RollbackErr} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// DualUploader stores every file on a primary and a standby server, for disaster
+// recovery procedures requiring mirrored drops. Uploads are all-or-nothing: when one
+// server fails, the copy stored on the other one is deleted.
+type DualUploader struct {
+	Primary *FtpServerConn
+	Standby *FtpServerConn
+	Mode    DualMode
+}
+
+// NewDualUploader returns a DualUploader storing files on primary and standby.
+func NewDualUploader(primary, standby *FtpServerConn, mode DualMode) *DualUploader {
+	return &DualUploader{Primary: primary, Standby: standby, Mode: mode}
+}
+
+// StorFile stores local as remote on both servers, as FtpServerConn.StorFile does.
+func (u *DualUploader) StorFile(local, remote string) error {
+	return u.StorFileContext(context.Background(), local, remote)
+}
+
+// StorFileContext is like StorFile but honors ctx. Marker files are only written
+// once the file is stored on both servers.
+func (u *DualUploader) StorFileContext(ctx context.Context, local, remote string) error {
+	store := func(c *FtpServerConn) error {
+		return c.withContext(ctx, func() error {
+			return c.storFileRetry(local, c.mapName(remote))
+		})
+	}
+
+	var primaryErr, standbyErr error
+	primaryStored, standbyStored := false, false
+	switch u.Mode {
+	case DualConcurrent:
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			standbyErr = store(u.Standby)
+		}()
+		primaryErr = store(u.Primary)
+		<-done
+		primaryStored, standbyStored = primaryErr == nil, standbyErr == nil
+	default:
+		primaryErr = store(u.Primary)
+		primaryStored = primaryErr == nil
+		if primaryStored {
+			standbyErr = store(u.Standby)
+			standbyStored = standbyErr == nil
+		}
+	}
+
+	if primaryStored && standbyStored {
+		primaryErr = u.deliver(ctx, u.Primary, local, remote)
+		if primaryErr == nil {
+			standbyErr = u.deliver(ctx, u.Standby, local, remote)
+		}
+		if primaryErr == nil && standbyErr == nil {
+			return nil
+		}
+	}
+
+	var rollbackErrs []error
+	if primaryStored {
+		rollbackErrs = append(rollbackErrs, u.rollback(ctx, u.Primary, remote))
+	}
+	if standbyStored {
+		rollbackErrs = append(rollbackErrs, u.rollback(ctx, u.Standby, remote))
+	}
+	return &DualUploadError{Primary: primaryErr, Standby: standbyErr, RollbackErr: errors.Join(rollbackErrs...)}
+}
+
+func (u *DualUploader) deliver(ctx context.Context, c *FtpServerConn, local, remote string) error {
+	return c.withContext(ctx, func() error {
+		return c.deliver(local, c.mapName(remote))
+	})
+}
+
+// rollback removes the file stored on c and its marker. Files that were not stored
+// are not an error.
+func (u *DualUploader) rollback(ctx context.Context, c *FtpServerConn, remote string) error {
+	return c.withContext(ctx, func() error {
+		name := c.mapName(remote)
+		if c.markerNamer != nil {
+			if marker := c.markerNamer(name); marker != "" {
+				c.dele(marker)
+			}
+		}
+		err := c.dele(name)
+		if err != nil && replycode.IsPermanentNegative(replyCode(err)) {
+			// nothing was stored
+			return nil
+		}
+		return err
+	})
+}
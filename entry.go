@@ -0,0 +1,107 @@
+package ftpclient
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Entry is a listed file with the details os.FileInfo has no room for. Fields the
+// listing format does not carry are left empty; Facts holds the MLSD facts keyed by
+// their lower case names and Raw the listing line.
+type Entry struct {
+	Name       string
+	Size       int64
+	Mode       os.FileMode
+	ModTime    time.Time
+	Owner      string
+	Group      string
+	LinkTarget string
+	Facts      map[string]string
+	Raw        string
+}
+
+// NewEntry returns the Entry of info. FileInfos returned by Dir and Walk carry all
+// the details parsed from the listing; others only provide their os.FileInfo fields.
+func NewEntry(info os.FileInfo) *Entry {
+	if f, ok := info.(*fileInfo); ok {
+		return &Entry{
+			Name:       f.name,
+			Size:       f.size,
+			Mode:       f.mode,
+			ModTime:    f.mtime,
+			Owner:      f.owner,
+			Group:      f.group,
+			LinkTarget: f.target,
+			Facts:      f.facts,
+			Raw:        f.raw,
+		}
+	}
+
+	e := &Entry{
+		Name:    info.Name(),
+		Size:    info.Size(),
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+	}
+	if raw, ok := info.Sys().(string); ok {
+		e.Raw = raw
+	}
+	return e
+}
+
+// IsDir reports whether the entry describes a directory.
+func (e *Entry) IsDir() bool {
+	return e.Mode.IsDir()
+}
+
+// FileInfo returns the entry as an os.FileInfo.
+func (e *Entry) FileInfo() os.FileInfo {
+	return &fileInfo{
+		name:   e.Name,
+		size:   e.Size,
+		mode:   e.Mode,
+		mtime:  e.ModTime,
+		raw:    e.Raw,
+		owner:  e.Owner,
+		group:  e.Group,
+		target: e.LinkTarget,
+		facts:  e.Facts,
+	}
+}
+
+// Entries is like Dir but returns the entries with their owner, group and link target.
+func (c *FtpServerConn) Entries(args ...string) ([]*Entry, error) {
+	infos, err := c.Dir(args...)
+	if err != nil {
+		return nil, err
+	}
+	return newEntries(infos), nil
+}
+
+// EntriesContext is like Entries but honors ctx.
+func (c *FtpServerConn) EntriesContext(ctx context.Context, args ...string) (entries []*Entry, err error) {
+	err = c.withContext(ctx, func() error {
+		entries, err = c.Entries(args...)
+		return err
+	})
+	return entries, err
+}
+
+// ReadEntries returns the entries of dir sorted by name, without "." and "..". It
+// lists with MLSD, falling back to LIST when the server does not implement it.
+func (c *FtpServerConn) ReadEntries(dir string) ([]*Entry, error) {
+	infos, err := c.readDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	return newEntries(infos), nil
+}
+
+func newEntries(infos []os.FileInfo) []*Entry {
+	entries := make([]*Entry, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, NewEntry(info))
+	}
+	return entries
+}
@@ -6,7 +6,6 @@ import (
 	"log"
 	"net"
 	"strconv"
-	"sync"
 	"time"
 
 	"github.com/tsujimic/ftpclient-go"
@@ -59,11 +58,6 @@ func main() {
 		panic(err)
 	}
 
-	err = source.Type("I")
-	if err != nil {
-		panic(err)
-	}
-
 	// connect destination
 	destinationAddr := net.JoinHostPort(host2, strconv.Itoa(port2))
 	destination := ftpclient.New(cfg)
@@ -78,49 +72,13 @@ func main() {
 		panic(err)
 	}
 
-	err = destination.Type("I")
-	if err != nil {
-		panic(err)
-	}
-
 	// start server-to-server FTP transfer
 	log.Println("Start server-to-server FTP transfer")
 
-	host, port, err := source.Pasv()
-	if err != nil {
-		panic(err)
-	}
-
-	err = destination.Port(host, port)
-	if err != nil {
-		panic(err)
-	}
-
-	err = destination.Stor(path2)
-	if err != nil {
-		panic(err)
-	}
-
-	err = source.Retr(path1)
+	tm := time.Duration(timeout) * time.Second
+	err = ftpclient.Fxp(source, path1, destination, path2, ftpclient.FxpTimeout(tm))
 	if err != nil {
 		panic(err)
 	}
-
-	// wait response
-	tm := time.Duration(timeout) * time.Second
-	var wg sync.WaitGroup
-	wg.Add(1)
-	go func() {
-		source.GetResponse(226, tm)
-		defer wg.Done()
-	}()
-
-	wg.Add(1)
-	go func() {
-		destination.GetResponse(226, tm)
-		defer wg.Done()
-	}()
-
-	wg.Wait()
 	log.Println("Done!!!")
 }
@@ -26,7 +26,13 @@ func main() {
 	flag.Parse()
 
 	log.Println("Start")
-	cfg := ftpclient.NewConfig()
+	cfg := ftpclient.NewConfig().WithProgress(func(n, total int64) {
+		if total > 0 {
+			fmt.Printf("\r%d / %d bytes (%.1f%%)", n, total, float64(n)*100/float64(total))
+		} else {
+			fmt.Printf("\r%d bytes", n)
+		}
+	})
 	client := ftpclient.New(cfg)
 	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	err := client.DialTimeout(addr, 30*time.Second)
@@ -40,7 +46,7 @@ func main() {
 		panic(err)
 	}
 
-	err = client.Type("I")
+	err = client.Type(ftpclient.TypeBinary)
 	if err != nil {
 		panic(err)
 	}
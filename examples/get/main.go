@@ -37,18 +37,16 @@ func main() {
 		panic(err)
 	}
 
-	err = client.Type("I")
+	err = client.Type(ftpclient.TypeBinary)
 	if err != nil {
 		panic(err)
 	}
 
 	client.SetPasv(false)
-	start := time.Now()
-	err = client.RetrFile(remote, local)
+	stats, err := client.RetrFileStats(remote, local)
 	if err != nil {
 		panic(err)
 	}
 
-	sec := (time.Now().Sub(start)).Seconds()
-	log.Printf("Stopwatch : %f seconds", sec)
+	log.Printf("Stopwatch : %f seconds, %d bytes", stats.Duration.Seconds(), stats.Bytes)
 }
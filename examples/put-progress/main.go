@@ -3,10 +3,8 @@ package main
 import (
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net"
-	"os"
 	"strconv"
 	"time"
 
@@ -26,7 +24,13 @@ func main() {
 	flag.Parse()
 
 	log.Println("Start")
-	cfg := ftpclient.NewConfig()
+	cfg := ftpclient.NewConfig().WithProgress(func(n, total int64) {
+		if total > 0 {
+			fmt.Printf("\r%d / %d bytes (%.1f%%)", n, total, float64(n)*100/float64(total))
+		} else {
+			fmt.Printf("\r%d bytes", n)
+		}
+	})
 	client := ftpclient.New(cfg)
 	addr := net.JoinHostPort(host, strconv.Itoa(port))
 	err := client.DialTimeout(addr, 30*time.Second)
@@ -40,53 +44,17 @@ func main() {
 		panic(err)
 	}
 
-	err = client.Type("I")
-	if err != nil {
-		panic(err)
-	}
-
-	file, err := os.Open(local)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-
-	fi, err := file.Stat()
+	err = client.Type(ftpclient.TypeBinary)
 	if err != nil {
 		panic(err)
 	}
-	filesize := fi.Size()
 
 	client.SetPasv(false)
-	writer, err := client.StorRequest(remote)
+	stats, err := client.StorFileStats(local, remote)
 	if err != nil {
 		panic(err)
 	}
-	defer writer.Close()
-
-	start := time.Now()
-	buf := make([]byte, 32*1024)
-	for {
-		nr, err := file.Read(buf)
-		if nr > 0 {
-			nw, err := writer.Write(buf[:nr])
-			if err != nil {
-				panic(err)
-			}
-			if nr != nw {
-				panic(io.ErrShortWrite)
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			panic(err)
-		}
-	}
+	fmt.Println()
 
-	now := time.Now()
-	sec := (now.Sub(start)).Seconds()
-	transferbps := (float64(filesize) / sec) * 8
-	fmt.Printf("Stopwatch : %f seconds, %f Mbit/s", sec, transferbps/1048576)
+	fmt.Printf("Stopwatch : %f seconds, %f Mbit/s, first byte after %v", stats.Duration.Seconds(), stats.Throughput*8/1048576, stats.TimeToFirstByte)
 }
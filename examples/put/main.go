@@ -38,19 +38,17 @@ func main() {
 		panic(err)
 	}
 
-	err = client.Type("I")
+	err = client.Type(ftpclient.TypeBinary)
 	if err != nil {
 		panic(err)
 	}
 
 	client.SetPasv(false)
-	start := time.Now()
-	err = client.StorFile(local, remote)
+	stats, err := client.StorFileStats(local, remote)
 	if err != nil {
 		panic(err)
 	}
 
-	sec := (time.Now().Sub(start)).Seconds()
-	msg := fmt.Sprintf("Stopwatch : %f seconds\n", sec)
+	msg := fmt.Sprintf("Stopwatch : %f seconds, %d bytes\n", stats.Duration.Seconds(), stats.Bytes)
 	log.Println(msg)
 }
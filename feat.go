@@ -0,0 +1,95 @@
+package ftpclient
+
+import (
+	"strings"
+)
+
+// Features are the extensions listed by FEAT (RFC 2389), keyed by upper case name,
+// with their parameters, e.g. "MLST" -> "type*;size*;modify*;" or "REST" -> "STREAM".
+type Features map[string]string
+
+// Has reports whether the feature name is listed.
+func (f Features) Has(name string) bool {
+	_, ok := f[strings.ToUpper(name)]
+	return ok
+}
+
+// Params returns the parameters of the feature name, and whether it is listed.
+func (f Features) Params(name string) (string, bool) {
+	params, ok := f[strings.ToUpper(name)]
+	return params, ok
+}
+
+// parseFeat parses a FEAT reply. The first and last lines are free text; each
+// feature line starts with a space. The parameters of features listed on several
+// lines, such as "SITE CHMOD" and "SITE UTIME", are joined with ';'.
+func parseFeat(msg string) Features {
+	features := make(Features)
+	lines := strings.Split(msg, "\n")
+	if len(lines) < 3 {
+		return features
+	}
+
+	for _, line := range lines[1 : len(lines)-1] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, params, _ := strings.Cut(line, " ")
+		name, params = strings.ToUpper(name), strings.TrimSpace(params)
+		if prev := features[name]; prev != "" && params != "" {
+			params = prev + ";" + params
+		}
+		features[name] = params
+	}
+	return features
+}
+
+// HasFeature reports whether the server lists the feature name in its FEAT reply.
+// It is false when FEAT fails.
+func (c *FtpServerConn) HasFeature(name string) bool {
+	features, err := c.Feat()
+	return err == nil && features.Has(name)
+}
+
+// FeatureParams returns the parameters the server lists for the feature name in its
+// FEAT reply, and whether it is listed.
+func (c *FtpServerConn) FeatureParams(name string) (string, bool) {
+	features, err := c.Feat()
+	if err != nil {
+		return "", false
+	}
+	return features.Params(name)
+}
+
+// Languages returns the languages listed by the LANG feature (RFC 2640), e.g.
+// "EN*;FR;DE" lists EN, FR and DE, and current is the one marked with '*'.
+func (f Features) Languages() (langs []string, current string) {
+	return f.starredList("LANG")
+}
+
+// HashAlgorithms returns the algorithms listed by the HASH feature, e.g.
+// "SHA-1;SHA-256*;MD5", and current is the selected one, marked with '*'.
+func (f Features) HashAlgorithms() (algos []string, current string) {
+	return f.starredList("HASH")
+}
+
+// starredList parses the parameters of the feature name as a ';' separated list where
+// the current item is marked with '*'.
+func (f Features) starredList(name string) (items []string, current string) {
+	params, ok := f.Params(name)
+	if !ok {
+		return nil, ""
+	}
+	for _, item := range strings.Split(params, ";") {
+		item = strings.TrimSpace(item)
+		if strings.HasSuffix(item, "*") {
+			item = strings.TrimSuffix(item, "*")
+			current = item
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items, current
+}
@@ -0,0 +1,25 @@
+package ftpclient
+
+import "testing"
+
+func TestParseFeat(t *testing.T) {
+	// go test -v -run TestParseFeat
+	msg := "Features:\n MDTM\n REST STREAM\n MLST type*;size*;modify*;\n utf8\nEnd"
+	features := parseFeat(msg)
+
+	if !features.Has("MDTM") || !features.Has("UTF8") || features.Has("MLSD") {
+		t.Errorf("parseFeat(%q) = %v", msg, features)
+	}
+	if params, ok := features.Params("rest"); !ok || params != "STREAM" {
+		t.Errorf("Params(rest) = %q, %v", params, ok)
+	}
+	if params, _ := features.Params("MLST"); params != "type*;size*;modify*;" {
+		t.Errorf("Params(MLST) = %q", params)
+	}
+	if langs, current := parseFeat("Features:\n LANG EN*;FR;DE\nEnd").Languages(); len(langs) != 3 || current != "EN" {
+		t.Errorf("Languages() = %v, %q", langs, current)
+	}
+	if features := parseFeat("no features"); len(features) != 0 {
+		t.Errorf("parseFeat(single line) = %v", features)
+	}
+}
@@ -0,0 +1,211 @@
+package ftpclient
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// FS is an fs.FS backed by a FtpServerConn, so that remote trees can be used with
+// fs.WalkDir, template loading or http.FS. Files are streamed with RETR; seeking
+// restarts the transfer with REST. Since a control connection carries one transfer
+// at a time, only one file may be read at once and FS is not safe for concurrent use.
+type FS struct {
+	c    *FtpServerConn
+	root string
+}
+
+// NewFS returns an FS rooted at the remote directory root.
+func NewFS(c *FtpServerConn, root string) *FS {
+	return &FS{c: c, root: root}
+}
+
+func (f *FS) remote(name string) string {
+	return path.Join(f.root, name)
+}
+
+// Open implements fs.FS.
+func (f *FS) Open(name string) (fs.File, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: unwrapPathError(err)}
+	}
+
+	if info.IsDir() {
+		return &fsDir{fs: f, name: name, info: info}, nil
+	}
+	return &fsFile{fs: f, name: name, info: info}, nil
+}
+
+// ReadDir implements fs.ReadDirFS.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	infos, err := f.c.readDir(f.remote(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(infos))
+	for _, info := range infos {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	return entries, nil
+}
+
+// Stat implements fs.StatFS. The entry is looked up in the listing of its parent.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return fileInfo{name: ".", mode: os.ModeDir}, nil
+	}
+
+	infos, err := f.c.readDir(f.remote(path.Dir(name)))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	base := path.Base(name)
+	for _, info := range infos {
+		if info.Name() == base {
+			return info, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func unwrapPathError(err error) error {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		return pathErr.Err
+	}
+	return err
+}
+
+// fsFile is a remote file opened through FS.
+type fsFile struct {
+	fs     *FS
+	name   string
+	info   fs.FileInfo
+	reader io.ReadCloser
+	offset int64
+}
+
+func (f *fsFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *fsFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		if f.offset >= f.info.Size() && f.info.Size() > 0 {
+			return 0, io.EOF
+		}
+		reader, err := f.fs.c.RetrRequestFrom(f.fs.remote(f.name), uint64(f.offset))
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+		f.reader = reader
+	}
+
+	n, err := f.reader.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// Seek implements io.Seeker by restarting the transfer at the new offset.
+func (f *fsFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset == f.offset {
+		return offset, nil
+	}
+
+	if err := f.closeReader(); err != nil {
+		return 0, err
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *fsFile) Close() error {
+	return f.closeReader()
+}
+
+// closeReader ends the current transfer, aborting it when it is incomplete.
+func (f *fsFile) closeReader() error {
+	if f.reader == nil {
+		return nil
+	}
+	reader := f.reader
+	f.reader = nil
+
+	err := reader.Close()
+	if f.offset < f.info.Size() {
+		// the server reports the early close as a failed transfer
+		var aborted *TransferAbortedError
+		if errors.As(err, &aborted) {
+			return nil
+		}
+	}
+	return err
+}
+
+// fsDir is a remote directory opened through FS.
+type fsDir struct {
+	fs      *FS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	read    bool
+}
+
+func (d *fsDir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *fsDir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("is a directory")}
+}
+
+func (d *fsDir) Close() error {
+	return nil
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *fsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.read {
+		entries, err := d.fs.ReadDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.read = true
+	}
+
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(d.entries) {
+		n = len(d.entries)
+	}
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
@@ -3,16 +3,23 @@ package ftpclient
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/textproto"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	"github.com/tsujimic/ftpclient-go/replycode"
 )
 
 const (
@@ -42,16 +49,76 @@ const (
 // FtpServerConn represents the connection to a remote FTP server.
 type FtpServerConn struct {
 	*Config
-	passive       bool
-	textprotoConn *textproto.Conn
-	conn          net.Conn
+	passive          bool
+	textprotoConn    *textproto.Conn
+	conn             net.Conn
+	addr             string
+	user             string
+	password         string
+	transferType     TransferType
+	epsvAllSent      bool
+	ctxMu            sync.Mutex
+	ctx              context.Context
+	ctxCanceled      bool
+	ctxConns         []net.Conn
+	ctxListener      net.Listener
+	siteCommands     map[string]bool
+	features         Features
+	trashReady       bool
+	hostSent         bool
+	dataProt         ProtectionLevel
+	tlsSession       *tls.Config
+	tempDirPath      string
+	handle           *TransferHandle
+	lastCode         int
+	lastMsg          string
+	welcome          string
+	mlsdUnsupported  bool
+	mlstUnsupported  bool
+	epsvRejected     bool
+	cwd              string
+	kaMu             sync.Mutex
+	pending          int
+	preliminary      bool
+	noops            int
+	stats            int
+	lastStat         string
+	lastActivity     time.Time
+	keepAliveStop    chan struct{}
+	recorder         *transferRecorder
+	transferLog      *transferLog
+	command          string
+	detectedCharset  *Charset
+	detectedLocation *time.Location
 }
 
 // FtpDataConn represent a data-connection
 type FtpDataConn struct {
-	conn net.Conn
-	c    *FtpServerConn
-}
+	conn     net.Conn
+	c        *FtpServerConn
+	replied  bool
+	reply    string
+	stop     func() error
+	n        int64
+	watchdog *stallWatchdog
+	total    int64
+	reported time.Time
+	listing  bool
+	logged   time.Time
+	command  string
+	opened   time.Time
+	aborting bool
+	closed   bool
+}
+
+// EPRT address families (RFC 2428).
+const (
+	EprtIPv4 = 1
+	EprtIPv6 = 2
+)
+
+// ErrEpsvAll is returned by Pasv, Port and Eprt after EPSV ALL has been sent.
+var ErrEpsvAll = errors.New("EPSV ALL in effect: only EPSV is allowed")
 
 var regexp227 = regexp.MustCompile("([0-9]+),([0-9]+),([0-9]+),([0-9]+),([0-9]+),([0-9]+)")
 var regexp229 = regexp.MustCompile("\\|\\|\\|([0-9]+)\\|")
@@ -65,7 +132,7 @@ func init() {
 func New(cfg *Config) *FtpServerConn {
 	c := &FtpServerConn{
 		Config:  cfg,
-		passive: false,
+		passive: cfg.passive,
 	}
 	return c
 }
@@ -77,79 +144,200 @@ func (c *FtpServerConn) Dial(addr string) error {
 
 // DialTimeout ...
 func (c *FtpServerConn) DialTimeout(addr string, timeout time.Duration) error {
-	var conn net.Conn
-	var err error
+	return c.dial(context.Background(), addr, timeout)
+}
 
-	if c.tlsConfig != nil && c.tlsImplicit == true {
-		dialer := &net.Dialer{
-			Timeout: timeout,
+// dial connects to addr through the configured CircuitBreaker, if any.
+func (c *FtpServerConn) dial(ctx context.Context, addr string, timeout time.Duration) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if timeout == 0 {
+		timeout = c.connectTimeout
+	}
+	if c.circuitBreaker == nil {
+		return c.connect(ctx, addr, timeout)
+	}
+
+	probe, err := c.circuitBreaker.Allow(addr)
+	if err != nil {
+		return err
+	}
+
+	err = c.connect(ctx, addr, timeout)
+	if err == nil && probe {
+		// servers may refuse NOOP before login; any reply shows the host is up
+		var perr *textproto.Error
+		if err = c.withContext(ctx, c.Noop); errors.As(err, &perr) {
+			err = nil
 		}
-		conn, err = tls.DialWithDialer(dialer, network, addr, c.tlsConfig)
-	} else {
-		conn, err = net.DialTimeout(network, addr, timeout)
 	}
 	if err != nil {
+		c.circuitBreaker.Failure(addr)
 		return err
 	}
+	c.circuitBreaker.Success(addr)
+	return nil
+}
 
-	textprotoConn := textproto.NewConn(conn)
-	c.textprotoConn = textprotoConn
-	c.conn = conn
-	_, _, err = c.getResponse(ServiceReadyForNewUser)
+// connect connects the control connection and reads the server greeting.
+func (c *FtpServerConn) connect(ctx context.Context, addr string, timeout time.Duration) error {
+	conn, err := c.dialConn(ctx, addr, timeout)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if c.proxyHeader != nil {
+		if err = c.proxyHeader.write(conn); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+
+	if c.tlsConfig != nil {
+		c.tlsSession = c.newTLSSession(addr)
+	}
+	if c.tlsConfig != nil && c.tlsImplicit == true {
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		tlsConn := tls.Client(conn, c.tlsSession)
+		if err = tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+	}
+
+	c.kaMu.Lock()
+	c.pending, c.noops, c.stats = 0, 0, 0
+	c.lastActivity = time.Now()
+	c.kaMu.Unlock()
+	c.setConn(conn)
+	c.addr = addr
+	// state negotiated with the server does not carry over to a new connection
+	c.hostSent = false
+	c.dataProt = ""
+	c.epsvAllSent = false
+	c.epsvRejected = false
+	c.features = nil
+	c.siteCommands = nil
+	return c.withContext(ctx, func() error {
+		code, msg, err := c.getResponse(ServiceReadyForNewUser)
+		if err != nil {
+			return err
+		}
+		c.welcome = msg
+
+		if err = c.checkReplyPolicy(code, msg); err != nil {
+			return err
+		}
+		c.startKeepAlive()
+		return nil
+	})
 }
 
 // Login as the given user.
 func (c *FtpServerConn) Login(user, password string) error {
+	if err := c.sendHost(); err != nil {
+		return err
+	}
 
 	if c.tlsConfig != nil && c.tlsImplicit == false {
 		if err := c.Auth("TLS"); err != nil {
 			return err
 		}
 
-		conn := tls.Client(c.conn, c.tlsConfig)
-		textprotoConn := textproto.NewConn(conn)
-		c.textprotoConn = textprotoConn
-		c.conn = conn
+		c.setConn(tls.Client(c.conn, c.tlsSession))
 
-		if err := c.Pbsz("0"); err != nil {
+		if err := c.protect(); err != nil {
 			return err
 		}
-
-		if err := c.Prot("P"); err != nil {
+	} else if c.tlsConfig != nil && c.protLevel != "" {
+		if err := c.protect(); err != nil {
 			return err
 		}
 	}
 
-	code, message, err := c.SendCmd(-1, "USER %s", user)
+	var code int
+	var message string
+	err := c.retry(func(attempt int) (err error) {
+		code, message, err = c.userPass(user, password)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	if code == UserNameOK {
-		_, _, err = c.SendCmd(UserLoggedIn, "PASS %s", password)
-		if err != nil {
-			return err
-		}
-		return nil
+	if err = c.checkReplyPolicy(code, message); err != nil {
+		return err
+	}
+	c.user = user
+	c.password = password
+	if c.epsvAll {
+		return c.EpsvAll()
+	}
+	return nil
+}
+
+// userPass sends USER, and PASS when the server asks for a password.
+func (c *FtpServerConn) userPass(user, password string) (int, string, error) {
+	code, message, err := c.SendCmd(-1, "USER %s", user)
+	if err != nil {
+		return code, message, err
 	}
 
-	return errors.New(message)
+	switch code {
+	case UserLoggedIn:
+		// the server does not require a password, as some anonymous servers do
+	case UserNameOK:
+		return c.SendCmd(UserLoggedIn, "PASS %s", password)
+	default:
+		return code, message, &textproto.Error{Code: code, Msg: message}
+	}
+	return code, message, nil
 }
 
-// Type issues a TYPE FTP command
-func (c *FtpServerConn) Type(param string) error {
-	_, _, err := c.SendCmd(CommandOkay, "TYPE %s", param)
+// LoginAnonymous logs in as the anonymous user, using email as password.
+// An empty email is replaced with "anonymous@", and an "@" is appended when missing
+// for servers requiring an email-formatted password. When "anonymous" is refused,
+// the conventional "ftp" guest account is tried.
+func (c *FtpServerConn) LoginAnonymous(email string) error {
+	if email == "" {
+		email = "anonymous@"
+	} else if !strings.Contains(email, "@") {
+		email += "@"
+	}
+
+	err := c.Login("anonymous", email)
+	if e, ok := err.(*textproto.Error); ok && e.Code == NotLoggedIn {
+		return c.Login("ftp", email)
+	}
 	return err
 }
 
+// Type issues a TYPE FTP command
+func (c *FtpServerConn) Type(param TransferType) error {
+	if !param.Valid() {
+		return fmt.Errorf("invalid transfer type: %q", string(param))
+	}
+	_, _, err := c.SendCmd(CommandOkay, "TYPE %s", string(param))
+	if err != nil {
+		return err
+	}
+	c.transferType = param
+	return nil
+}
+
 // Cwd issues a CWD FTP command, which changes the current directory to the specified path.
 func (c *FtpServerConn) Cwd(path string) error {
 	_, _, err := c.SendCmd(ActionOK, "CWD %s", path)
+	if err == nil {
+		c.trackCwd(path)
+	}
 	return err
 }
 
@@ -157,6 +345,9 @@ func (c *FtpServerConn) Cwd(path string) error {
 // This is similar to a call to ChangeDir with a path set to "..".
 func (c *FtpServerConn) Cdup() error {
 	_, _, err := c.SendCmd(ActionOK, "CDUP")
+	if err == nil {
+		c.trackCwd("..")
+	}
 	return err
 }
 
@@ -167,7 +358,11 @@ func (c *FtpServerConn) Pwd() (string, error) {
 		return "", err
 	}
 
-	return parse257(msg)
+	dir, err := c.parsePathReply(msg)
+	if err == nil {
+		c.trackCwd(dir)
+	}
+	return dir, err
 }
 
 // Rename renames a file on the remote FTP server.
@@ -181,7 +376,16 @@ func (c *FtpServerConn) Rename(from, to string) error {
 }
 
 // Delete issues a DELE FTP command to delete the specified file from the remote FTP server.
+// When a trash directory is configured, the file is moved there instead.
 func (c *FtpServerConn) Delete(path string) error {
+	if c.trashes(path) {
+		return c.moveToTrash(path)
+	}
+	return c.dele(path)
+}
+
+// dele deletes path with DELE.
+func (c *FtpServerConn) dele(path string) error {
 	code, msg, err := c.SendCmd(-1, "DELE %s", path)
 	if err != nil {
 		return err
@@ -199,7 +403,7 @@ func (c *FtpServerConn) Mkd(path string) (string, error) {
 		return "", err
 	}
 
-	return parse257(msg)
+	return c.parsePathReply(msg)
 }
 
 // Rmd issues a RMD FTP command to remove the specified directory from the remote FTP server.
@@ -227,8 +431,18 @@ func (c *FtpServerConn) Rein() error {
 }
 
 // Abort a file transfer that is in progress.
+// ABOR is preceded by the Telnet interrupt sequence; when a transfer was interrupted,
+// its 426 reply is read before the reply to ABOR. To abort a transfer started with
+// a request method, use the Abort method of the returned FtpDataConn instead.
 func (c *FtpServerConn) Abort() error {
-	code, msg, err := c.SendCmd(-1, "ABOR")
+	if err := c.putAbort(); err != nil {
+		return err
+	}
+	code, msg, err := c.getResponse(-1)
+	if err == nil && replycode.IsNegative(code) {
+		// the reply to an interrupted transfer comes first
+		code, msg, err = c.getResponse(-1)
+	}
 	if err != nil {
 		return err
 	}
@@ -248,11 +462,29 @@ func (c *FtpServerConn) Syst() (string, error) {
 	return msg, nil
 }
 
+// WelcomeMessage returns the greeting the server sent when the connection was opened,
+// with the lines of a multi-line greeting separated by newlines.
+func (c *FtpServerConn) WelcomeMessage() string {
+	return c.welcome
+}
+
+// LastResponse returns the code and message of the last reply read from the server.
+func (c *FtpServerConn) LastResponse() (int, string) {
+	return c.lastCode, c.lastMsg
+}
+
 // Quit issues a QUIT FTP command to properly close the connection from the remote FTP server.
 func (c *FtpServerConn) Quit() error {
-	c.SendCmd(-1, "QUIT")
+	c.stopKeepAlive()
+	cleanupErr := c.CleanupTempDir()
+	if cleanupErr != nil {
+		c.errorf("temp dir cleanup: %v", cleanupErr)
+	}
+	_, _, quitErr := c.SendCmd(-1, "QUIT")
 	//return c.conn.Close()
-	return c.textprotoConn.Close()
+	err := c.textprotoConn.Close()
+	c.secondary(&err, cleanupErr, quitErr)
+	return err
 }
 
 // Size Request the size of the file named filename on the server.
@@ -267,6 +499,17 @@ func (c *FtpServerConn) Size(filename string) (int, error) {
 	return strconv.Atoi(strings.TrimSpace(msg))
 }
 
+// ModTime issues a MDTM FTP command and returns the modification time of the file
+// named filename in UTC. ftp server extention command.
+func (c *FtpServerConn) ModTime(filename string) (time.Time, error) {
+	_, msg, err := c.SendCmd(213, "MDTM %s", filename)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return parseMlsxTime(strings.TrimSpace(msg))
+}
+
 // NlstRequest issues an NLST FTP command.
 func (c *FtpServerConn) NlstRequest(args ...string) (io.ReadCloser, error) {
 	cmd := append([]string{"NLST"}, args...)
@@ -277,7 +520,7 @@ func (c *FtpServerConn) NlstRequest(args ...string) (io.ReadCloser, error) {
 		return nil, err
 	}
 
-	return &FtpDataConn{conn, c}, nil
+	return c.newListingConn(conn), nil
 }
 
 // ListRequest issues a LIST FTP command.
@@ -289,7 +532,7 @@ func (c *FtpServerConn) ListRequest(args ...string) (io.ReadCloser, error) {
 		return nil, err
 	}
 
-	return &FtpDataConn{conn, c}, nil
+	return c.newListingConn(conn), nil
 }
 
 // RetrRequest issues a RETR FTP command to fetch the specified file from the remote FTP server
@@ -299,7 +542,19 @@ func (c *FtpServerConn) RetrRequest(path string) (io.ReadCloser, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &FtpDataConn{conn, c}, nil
+	return c.newDataConn(conn), nil
+}
+
+// RetrRequestFrom issues a REST FTP command followed by a RETR FTP command, so that the
+// returned ReadCloser starts at offset. No REST is sent when offset is zero.
+// The returned ReadCloser must be closed to cleanup the FTP data connection.
+func (c *FtpServerConn) RetrRequestFrom(path string, offset uint64) (io.ReadCloser, error) {
+	if offset > 0 {
+		if err := c.Rest(offset); err != nil {
+			return nil, err
+		}
+	}
+	return c.RetrRequest(path)
 }
 
 // StorRequest issues a STOR FTP command to store a file to the remote FTP server.
@@ -309,7 +564,34 @@ func (c *FtpServerConn) StorRequest(path string) (io.WriteCloser, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &FtpDataConn{conn, c}, nil
+	return c.newDataConn(conn), nil
+}
+
+// StorAt issues a REST FTP command followed by a STOR FTP command, so that the data
+// written to the returned WriteCloser is stored starting at offset of the remote file.
+// This requires a server honoring REST for STOR.
+// The returned WriteCloser must be closed to cleanup the FTP data connection.
+func (c *FtpServerConn) StorAt(path string, offset int64) (io.WriteCloser, error) {
+	if offset < 0 {
+		return nil, errors.New("negative offset")
+	}
+	if offset > 0 {
+		if err := c.Rest(uint64(offset)); err != nil {
+			return nil, err
+		}
+	}
+	return c.StorRequest(path)
+}
+
+// AppeRequest issues an APPE FTP command to append to a file on the remote FTP server.
+// The file is created when it does not exist.
+// The returned WriteCloser must be closed to cleanup the FTP data connection.
+func (c *FtpServerConn) AppeRequest(path string) (io.WriteCloser, error) {
+	conn, err := c.transferCmd("APPE %s", path)
+	if err != nil {
+		return nil, err
+	}
+	return c.newDataConn(conn), nil
 }
 
 // TransferRequest issues a FTP command to fetch the specified file from the remote FTP server
@@ -319,7 +601,7 @@ func (c *FtpServerConn) TransferRequest(format string, args ...interface{}) (io.
 	if err != nil {
 		return nil, err
 	}
-	return &FtpDataConn{conn, c}, nil
+	return c.newDataConn(conn), nil
 }
 
 // SetPasv sets the mode to passive or active for data transfers.
@@ -336,8 +618,10 @@ func (c *FtpServerConn) Nlst(args ...string) (lines []string, err error) {
 		return
 	}
 
-	r := &FtpDataConn{conn, c}
-	defer r.Close()
+	r := c.newListingConn(conn)
+	defer func() {
+		c.secondary(&err, r.Close())
+	}()
 
 	lines, err = c.getLines(r)
 	if err != nil {
@@ -355,8 +639,10 @@ func (c *FtpServerConn) List(args ...string) (lines []string, err error) {
 		return
 	}
 
-	r := &FtpDataConn{conn, c}
-	defer r.Close()
+	r := c.newListingConn(conn)
+	defer func() {
+		c.secondary(&err, r.Close())
+	}()
 
 	lines, err = c.getLines(r)
 	if err != nil {
@@ -365,8 +651,18 @@ func (c *FtpServerConn) List(args ...string) (lines []string, err error) {
 	return
 }
 
-// Dir issues a LIST FTP command.
+// Dir issues a LIST FTP command. Failed listings are retried according to the
+// configured RetryPolicy.
 func (c *FtpServerConn) Dir(args ...string) (infos []os.FileInfo, err error) {
+	err = c.retry(func(attempt int) error {
+		infos, err = c.dir(args...)
+		return err
+	})
+	return infos, err
+}
+
+// dir lists with LIST and parses the listing.
+func (c *FtpServerConn) dir(args ...string) (infos []os.FileInfo, err error) {
 	cmd := append([]string{"LIST"}, args...)
 	val := strings.Join(cmd, " ")
 	conn, err := c.transferCmd(val)
@@ -374,13 +670,15 @@ func (c *FtpServerConn) Dir(args ...string) (infos []os.FileInfo, err error) {
 		return
 	}
 
-	r := &FtpDataConn{conn, c}
-	defer r.Close()
+	r := c.newListingConn(conn)
+	defer func() {
+		c.secondary(&err, r.Close())
+	}()
 
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Text()
-		fileinfo, err := parse(line)
+		line := c.listingLine(scanner.Text())
+		fileinfo, err := c.parse(line)
 		if err == nil {
 			infos = append(infos, fileinfo)
 		}
@@ -416,21 +714,87 @@ func (c *FtpServerConn) Stor(path string) error {
 	return err
 }
 
+// Appe issues an APPE FTP command to append to a file on the remote FTP server.
+func (c *FtpServerConn) Appe(path string) error {
+	code, msg, err := c.SendCmd(-1, "APPE %s", path)
+	if err != nil {
+		return err
+	}
+	if code != 125 && code != 150 {
+		return &textproto.Error{Code: code, Msg: msg}
+	}
+	return err
+}
+
 // RetrFile issues a RETR FTP command to fetch the specified file from the remote FTP server
+// Failed transfers are retried according to the configured RetryPolicy and ResumePolicy.
 func (c *FtpServerConn) RetrFile(remote, local string) error {
+	return c.retry(func(attempt int) error {
+		return c.retrFileAttempt(remote, local, attempt)
+	})
+}
+
+// retrFileAttempt makes one attempt of RetrFile, resuming when a retry may.
+func (c *FtpServerConn) retrFileAttempt(remote, local string, attempt int) error {
+	if attempt > 0 && c.resumable(localSize(local)) {
+		return c.resumeRetrFile(remote, local)
+	}
+	return c.retrFile(remote, local)
+}
+
+// retrFile fetches remote into local, replacing any existing file.
+func (c *FtpServerConn) retrFile(remote, local string) (err error) {
+	var size int64 = -1
+	if c.preallocate {
+		if n, err := c.Size(remote); err == nil {
+			size = int64(n)
+		}
+	}
+
 	reader, err := c.RetrRequest(remote)
 	if err != nil {
 		return err
 	}
-	defer reader.Close()
+	defer func() {
+		if cerr := reader.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
 
 	file, err := os.Create(local)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	defer func() {
+		c.secondary(&err, file.Close())
+	}()
+
+	if size > 0 {
+		if err := file.Truncate(size); err != nil {
+			return err
+		}
+	}
+
+	var written int64
+	defer func() {
+		// the remote file may have shrunk since SIZE was issued, or the transfer
+		// failed; either way keep only what was written so it can be resumed
+		if size > 0 && written != size {
+			if terr := file.Truncate(written); terr != nil && err == nil {
+				err = terr
+			}
+		}
+	}()
+
+	if c.smallFileThreshold > 0 {
+		var done bool
+		written, done, err = c.copySmall(file, reader)
+		if err != nil || done {
+			return err
+		}
+	}
 
-	buf := make([]byte, 32*1024)
+	buf := make([]byte, c.bufferSize)
 	for {
 		nr, err := reader.Read(buf)
 		if nr > 0 {
@@ -441,6 +805,7 @@ func (c *FtpServerConn) RetrFile(remote, local string) error {
 			if nr != nw {
 				return io.ErrShortWrite
 			}
+			written += int64(nw)
 		}
 		if err == io.EOF {
 			break
@@ -453,8 +818,83 @@ func (c *FtpServerConn) RetrFile(remote, local string) error {
 	return nil
 }
 
+// AppendFile issues an APPE FTP command to append a local file to a file on the remote FTP server.
+func (c *FtpServerConn) AppendFile(local, remote string) (err error) {
+	file, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer, err := c.AppeRequest(remote)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	_, err = io.CopyBuffer(writer, file, make([]byte, c.bufferSize))
+	return err
+}
+
+// ResumeRetrFile continues an interrupted RetrFile, fetching the remainder of the remote
+// file from the current size of the local file onward.
+func (c *FtpServerConn) ResumeRetrFile(remote, local string) (err error) {
+	file, err := os.OpenFile(local, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.RetrTo(remote, file, uint64(offset))
+	return err
+}
+
 // StorFile issues a STOR FTP command to store a file to the remote FTP server.
+// Failed transfers are retried according to the configured RetryPolicy and ResumePolicy.
 func (c *FtpServerConn) StorFile(local, remote string) error {
+	remote = c.mapName(remote)
+	if err := c.storFileRetry(local, remote); err != nil {
+		return err
+	}
+	return c.deliver(local, remote)
+}
+
+// storFileRetry stores local as remote according to the RetryPolicy.
+func (c *FtpServerConn) storFileRetry(local, remote string) error {
+	return c.retry(func(attempt int) error {
+		return c.storFileAttempt(local, remote, attempt)
+	})
+}
+
+// storFileAttempt makes one attempt of StorFile, resuming when a retry may.
+func (c *FtpServerConn) storFileAttempt(local, remote string, attempt int) error {
+	if attempt > 0 && c.resumePolicy != nil {
+		if n, err := c.Size(remote); err == nil && c.resumable(int64(n)) {
+			return c.resumeStorFile(local, remote, int64(n))
+		}
+	}
+	return c.storFile(local, remote)
+}
+
+// storFile stores local as remote, replacing any existing file.
+func (c *FtpServerConn) storFile(local, remote string) (err error) {
+	if c.isSmallFile(local) {
+		return c.storSmallFile(local, remote)
+	}
+
 	file, err := os.Open(local)
 	if err != nil {
 		return err
@@ -465,9 +905,16 @@ func (c *FtpServerConn) StorFile(local, remote string) error {
 	if err != nil {
 		return err
 	}
-	defer writer.Close()
+	if info, err := file.Stat(); err == nil {
+		setTotal(writer, info.Size())
+	}
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
 
-	buf := make([]byte, 32*1024)
+	buf := make([]byte, c.bufferSize)
 	for {
 		nr, err := file.Read(buf)
 		if nr > 0 {
@@ -490,11 +937,50 @@ func (c *FtpServerConn) StorFile(local, remote string) error {
 	return nil
 }
 
+// RetrTo issues a RETR FTP command and copies the remote file to w, starting at offset.
+// A REST command is issued first when offset is not zero. It returns the number of bytes copied.
+func (c *FtpServerConn) RetrTo(remote string, w io.Writer, offset uint64) (n int64, err error) {
+	reader, err := c.RetrRequestFrom(remote, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	cw := c.newCheckpointWriter(w, remote, int64(offset))
+	n, err = io.CopyBuffer(cw, reader, make([]byte, c.bufferSize))
+	if cerr := reader.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if err == nil {
+		cw.done()
+	}
+	return n, err
+}
+
+// StorFrom issues a STOR FTP command and copies r to the remote file, starting at offset.
+// A REST command is issued first when offset is not zero. It returns the number of bytes copied.
+// Bytes handed to the data connection may not have been stored by the server, so the
+// checkpoint is only reported once the server confirmed the transfer.
+func (c *FtpServerConn) StorFrom(remote string, r io.Reader, offset uint64) (n int64, err error) {
+	writer, err := c.StorAt(remote, int64(offset))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = io.CopyBuffer(writer, r, make([]byte, c.bufferSize))
+	if cerr := writer.Close(); cerr != nil && err == nil {
+		err = cerr
+	}
+	if err == nil && c.checkpoint != nil {
+		c.checkpoint(remote, int64(offset)+n)
+	}
+	return n, err
+}
+
 // SendCmd Send a simple command string to the server and return the code and response string.
 func (c *FtpServerConn) SendCmd(expectCode int, format string, args ...interface{}) (int, string, error) {
 
 	if strings.HasPrefix(format, "PASS") {
-		c.log("PASS ***")
+		c.logf("PASS ***")
 	} else {
 		c.logf(format, args...)
 	}
@@ -507,8 +993,24 @@ func (c *FtpServerConn) SendCmd(expectCode int, format string, args ...interface
 	return c.getResponse(expectCode)
 }
 
+// SendCmdLines is like SendCmd but returns the reply as a slice of lines, so that
+// multi-line replies such as those to STAT, FEAT or SITE HELP can be consumed line by
+// line. The reply code is removed from the lines prefixed with it; other lines are
+// returned as sent, including the leading space of FEAT entries.
+func (c *FtpServerConn) SendCmdLines(expectCode int, format string, args ...interface{}) (int, []string, error) {
+	code, msg, err := c.SendCmd(expectCode, format, args...)
+	if code == 0 {
+		return code, nil, err
+	}
+	return code, strings.Split(msg, "\n"), err
+}
+
 // Pasv issues a "PASV" command to get a port number for a data connection.
 func (c *FtpServerConn) Pasv() (host string, port int, err error) {
+	if c.epsvAllSent {
+		err = ErrEpsvAll
+		return
+	}
 	_, line, err := c.SendCmd(227, "PASV")
 	if err != nil {
 		return
@@ -517,6 +1019,62 @@ func (c *FtpServerConn) Pasv() (host string, port int, err error) {
 	return parse227(line)
 }
 
+// PasvExternal is like Pasv but returns the address at which the server is reachable
+// from outside. Servers behind NAT advertise their internal address in the 227 reply;
+// when that address is private, loopback or unspecified while the control connection
+// peer is not, the control connection peer address is returned instead. The result
+// can be handed to the Port method of another connection for FXP.
+func (c *FtpServerConn) PasvExternal() (host string, port int, err error) {
+	host, port, err = c.Pasv()
+	if err != nil {
+		return
+	}
+	host, err = c.externalHost(host)
+	return
+}
+
+// Cpsv issues a CPSV FTP command, which is like PASV but makes the server act as the
+// TLS client on the protected data connection. It is used for FXP between servers
+// that both protect their data connections. As with PasvExternal, an internal
+// address advertised by a server behind NAT is replaced by its control address.
+// Like Pasv, Cpsv is not retried on its own; Fxp retries the whole transfer.
+func (c *FtpServerConn) Cpsv() (host string, port int, err error) {
+	if c.epsvAllSent {
+		err = ErrEpsvAll
+		return
+	}
+	_, line, err := c.SendCmd(227, "CPSV")
+	if err != nil {
+		return
+	}
+	host, port, err = parse227(line)
+	if err != nil {
+		return
+	}
+	host, err = c.externalHost(host)
+	return
+}
+
+// externalHost returns the control connection peer address in place of host when
+// host is private, loopback or unspecified while the peer is not.
+func (c *FtpServerConn) externalHost(host string) (string, error) {
+	peer, _, err := net.SplitHostPort(c.conn.RemoteAddr().String())
+	if err != nil {
+		return host, err
+	}
+	// when the peer itself is private both ends share a network and the reply is kept
+	peerIP := net.ParseIP(peer).To4()
+	if peerIP != nil && isRoutable(peerIP) && !isRoutable(net.ParseIP(host)) {
+		host = peer
+	}
+	return host, nil
+}
+
+// isRoutable reports whether ip is usable from another network.
+func isRoutable(ip net.IP) bool {
+	return !(ip.IsPrivate() || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast())
+}
+
 // Epsv issues a "EPSV" command to get a port number for a data connection.
 func (c *FtpServerConn) Epsv() (port int, err error) {
 	_, line, err := c.SendCmd(229, "EPSV")
@@ -527,9 +1085,33 @@ func (c *FtpServerConn) Epsv() (port int, err error) {
 	return parse229(line)
 }
 
+// EpsvAll issues a "EPSV ALL" command. Once accepted, PASV, PORT and EPRT are refused
+// and every data connection is opened with EPSV.
+func (c *FtpServerConn) EpsvAll() error {
+	_, _, err := c.SendCmd(229, "EPSV ALL")
+	if err != nil {
+		// some servers acknowledge EPSV ALL with 200
+		if e, ok := err.(*textproto.Error); !ok || e.Code != CommandOkay {
+			return err
+		}
+	}
+	c.epsvAllSent = true
+	return nil
+}
+
 // Port issues a PORT FTP command
 func (c *FtpServerConn) Port(host string, port int) error {
-	hostbytes := strings.Split(host, ".")
+	if c.epsvAllSent {
+		return ErrEpsvAll
+	}
+	ip := net.ParseIP(host).To4()
+	if ip == nil {
+		return fmt.Errorf("PORT requires an IPv4 address: %q", host)
+	}
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port number: %d", port)
+	}
+	hostbytes := strings.Split(ip.String(), ".")
 	portbytes := []string{strconv.Itoa(port / 256), strconv.Itoa(port % 256)}
 	param := strings.Join(append(hostbytes, portbytes...), ",")
 	_, _, err := c.SendCmd(CommandOkay, "PORT %s", param)
@@ -552,12 +1134,37 @@ func (c *FtpServerConn) port(addr net.Addr) error {
 
 // Eprt issues a EPRT FTP command
 func (c *FtpServerConn) Eprt(host string, port int) error {
-	addressfamily := 2
+	if c.epsvAllSent {
+		return ErrEpsvAll
+	}
+	addressfamily := EprtIPv6
 	ip := net.ParseIP(host)
 	if ip.To4() != nil {
-		addressfamily = 1
+		addressfamily = EprtIPv4
+	}
+	return c.EprtFamily(addressfamily, host, port)
+}
+
+// EprtFamily issues a EPRT FTP command with an explicit address family, EprtIPv4 or
+// EprtIPv6. It is used to advertise an externally visible address, as needed for FXP
+// between servers behind NAT.
+func (c *FtpServerConn) EprtFamily(family int, host string, port int) error {
+	if c.epsvAllSent {
+		return ErrEpsvAll
 	}
-	_, _, err := c.SendCmd(CommandOkay, "EPRT |%d|%s|%d|", addressfamily, host, port)
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return fmt.Errorf("invalid EPRT address: %q", host)
+	case family == EprtIPv4 && ip.To4() == nil, family == EprtIPv6 && ip.To4() != nil:
+		return fmt.Errorf("EPRT address %q does not match address family %d", host, family)
+	case family != EprtIPv4 && family != EprtIPv6:
+		return fmt.Errorf("unknown EPRT address family: %d", family)
+	}
+	if port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port number: %d", port)
+	}
+	_, _, err := c.SendCmd(CommandOkay, "EPRT |%d|%s|%d|", family, ip.String(), port)
 	return err
 }
 
@@ -588,16 +1195,29 @@ func (c *FtpServerConn) Pbsz(param string) error {
 	return err
 }
 
-// Prot issues a PROT FTP command
+// Prot issues a PROT FTP command. Data connections are wrapped in TLS unless the
+// level is C (clear).
 func (c *FtpServerConn) Prot(param string) error {
 	_, _, err := c.SendCmd(CommandOkay, "PROT %s", param)
+	if err == nil {
+		c.dataProt = ProtectionLevel(strings.ToUpper(param))
+	}
 	return err
 }
 
-// Feat issues a FEAT FTP command
-func (c *FtpServerConn) Feat() error {
-	_, _, err := c.SendCmd(211, "FEAT")
-	return err
+// Feat issues a FEAT FTP command and returns the features listed by the server.
+// The result is cached on the session.
+func (c *FtpServerConn) Feat() (Features, error) {
+	if c.features != nil {
+		return c.features, nil
+	}
+
+	_, msg, err := c.SendCmd(211, "FEAT")
+	if err != nil {
+		return nil, err
+	}
+	c.features = parseFeat(msg)
+	return c.features, nil
 }
 
 // Opts issues a OPTS FTP command
@@ -608,26 +1228,71 @@ func (c *FtpServerConn) Opts(param string) error {
 
 // GetResponse issues a FTP command response
 func (c *FtpServerConn) GetResponse(expectCode int, timeout time.Duration) (int, string, error) {
-	c.conn.SetReadDeadline(time.Now().Add(timeout))
+	c.setReadDeadline(c.conn, timeout)
 	return c.readResponse(expectCode)
 }
 
 // putCmd is a helper function to execute a command.
 func (c *FtpServerConn) putCmd(format string, args ...interface{}) error {
-	c.conn.SetWriteDeadline(time.Now().Add(c.readWriteTimeout))
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	c.command = commandVerb(format)
+	err := c.writeCmd(format, args...)
+	if err == nil {
+		c.pending++
+		c.lastActivity = time.Now()
+	}
+	return err
+}
+
+// writeCmd writes a command line to the control connection, encoded in the charset
+// of the session. kaMu must be held.
+func (c *FtpServerConn) writeCmd(format string, args ...interface{}) error {
+	c.setWriteDeadline(c.conn, c.timeout(c.commandTimeout))
+	c.traceCommand(format, args...)
+	if c.rfc2640 {
+		line, err := encodeCommand(fmt.Sprintf(format, args...))
+		if err != nil {
+			return err
+		}
+		_, err = c.textprotoConn.Cmd("%s", line)
+		return err
+	}
+	if cs, ok := c.sessionCharset(); ok && cs.Encoding != nil {
+		line, err := cs.encode(fmt.Sprintf(format, args...))
+		if err != nil {
+			return err
+		}
+		_, err = c.textprotoConn.Cmd("%s", line)
+		return err
+	}
 	_, err := c.textprotoConn.Cmd(format, args...)
 	return err
 }
 
 // getResponse is a helper function to check for the expected FTP return code
 func (c *FtpServerConn) getResponse(expectCode int) (int, string, error) {
-	c.conn.SetReadDeadline(time.Now().Add(c.readWriteTimeout))
+	c.setReadDeadline(c.conn, c.timeout(c.commandTimeout))
 	return c.readResponse(expectCode)
 }
 
 // readResponse is a helper function to check for the expected FTP return code
 func (c *FtpServerConn) readResponse(expectCode int) (int, string, error) {
-	code, message, err := c.textprotoConn.ReadResponse(expectCode)
+	code, message, err := c.readReply(expectCode)
+	for c.trackReply(code, message) {
+		c.logf("%d %s", code, message)
+		code, message, err = c.readReply(expectCode)
+	}
+	message = c.decodeReply(message)
+	if err != nil {
+		if protoErr, ok := err.(*textproto.Error); ok {
+			protoErr.Msg = message
+		}
+	}
+	if code != 0 {
+		c.lastCode, c.lastMsg = code, message
+	}
+	c.observeReply(code)
 	if err != nil {
 		return code, message, err
 	}
@@ -635,23 +1300,62 @@ func (c *FtpServerConn) readResponse(expectCode int) (int, string, error) {
 	return code, message, err
 }
 
-func (c *FtpServerConn) log(args ...interface{}) {
-	if c.logger != nil {
-		c.logger.Log(args...)
+// checkReplyPolicy passes a greeting or login reply to the configured ReplyPolicy
+// and closes the session when the policy rejects it.
+func (c *FtpServerConn) checkReplyPolicy(code int, msg string) error {
+	if c.replyPolicy == nil {
+		return nil
 	}
+	if err := c.replyPolicy(code, msg); err != nil {
+		c.Quit()
+		return &PolicyError{Code: code, Msg: msg, Err: err}
+	}
+	return nil
 }
 
-func (c *FtpServerConn) logf(format string, args ...interface{}) {
-	if c.logger != nil {
-		c.logger.Logf(format, args...)
+// logAt logs a message at level, under the transfer log of the current operation if any.
+func (c *FtpServerConn) logAt(level Level, format string, args ...interface{}) {
+	if level < c.logLevel {
+		return
+	}
+	logger := c.logger
+	if t := c.currentTransferLog(); t != nil {
+		logger = t.logger
+		format = "[%s] " + format
+		args = append([]interface{}{t.id}, args...)
 	}
+	if logger == nil {
+		return
+	}
+	if l, ok := logger.(LeveledLogger); ok {
+		l.LogLevel(level, format, args...)
+		return
+	}
+	logger.Logf(format, args...)
+}
+
+// logf logs the wire protocol at LevelDebug.
+func (c *FtpServerConn) logf(format string, args ...interface{}) {
+	c.logAt(LevelDebug, format, args...)
+}
+
+func (c *FtpServerConn) infof(format string, args ...interface{}) {
+	c.logAt(LevelInfo, format, args...)
+}
+
+func (c *FtpServerConn) warnf(format string, args ...interface{}) {
+	c.logAt(LevelWarn, format, args...)
+}
+
+func (c *FtpServerConn) errorf(format string, args ...interface{}) {
+	c.logAt(LevelError, format, args...)
 }
 
 // getLines
 func (c *FtpServerConn) getLines(r io.Reader) (lines []string, err error) {
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+		lines = append(lines, c.listingLine(scanner.Text()))
 	}
 	if err = scanner.Err(); err != nil {
 		return lines, err
@@ -662,19 +1366,26 @@ func (c *FtpServerConn) getLines(r io.Reader) (lines []string, err error) {
 // transferCmd
 func (c *FtpServerConn) transferCmd(format string, args ...interface{}) (conn net.Conn, err error) {
 	var listener net.Listener
-	if c.passive {
+	if c.passive || c.epsvAllSent {
 		host, port, err := c.makePasv()
 		if err != nil {
 			return nil, err
 		}
 
-		conn, err = net.DialTimeout(network, net.JoinHostPort(host, strconv.Itoa(port)), c.readWriteTimeout)
+		conn, err = c.dialConn(c.context(), net.JoinHostPort(host, strconv.Itoa(port)), c.timeout(c.connectTimeout))
 		if err != nil {
 			return nil, err
 		}
+		c.trackConn(conn)
+		if c.verifyPeer {
+			if err = c.verifyDataPeer(conn); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
 
-		if c.tlsConfig != nil {
-			conn = tls.Client(conn, c.tlsConfig)
+		if c.dataProtected() {
+			conn = tls.Client(conn, c.tlsSession)
 		}
 	} else {
 		listener, err = c.makePort()
@@ -682,6 +1393,7 @@ func (c *FtpServerConn) transferCmd(format string, args ...interface{}) (conn ne
 			return nil, err
 		}
 		defer listener.Close()
+		c.trackListener(listener)
 	}
 
 	code, msg, err := c.SendCmd(-1, format, args...)
@@ -697,11 +1409,25 @@ func (c *FtpServerConn) transferCmd(format string, args ...interface{}) (conn ne
 		if err != nil {
 			return nil, err
 		}
+		c.trackConn(conn)
+		if c.verifyPeer {
+			if err = c.verifyDataPeer(conn); err != nil {
+				conn.Close()
+				c.getResponse(-1)
+				return nil, err
+			}
+		}
 
-		if c.tlsConfig != nil {
+		if c.dataProtected() {
 			conn = tls.Server(conn, c.tlsConfig)
 			//c.stateTLSConn(conn)
 		}
+	} else if c.tlsSessionBinding && c.dataProtected() {
+		if err = c.verifyTLSSessionBinding(conn.(*tls.Conn)); err != nil {
+			conn.Close()
+			c.getResponse(-1)
+			return nil, err
+		}
 	}
 
 	return
@@ -713,7 +1439,7 @@ func (c *FtpServerConn) stateTLSConn(conn net.Conn) {
 		//c.log("upgraded connection to TLS")
 		err := tlsconn.Handshake()
 		if err != nil {
-			c.logf("handshake error: %v", err)
+			c.errorf("handshake error: %v", err)
 		}
 		state := tlsconn.ConnectionState()
 		c.logf("handshake complete: %v", state.HandshakeComplete)
@@ -721,16 +1447,30 @@ func (c *FtpServerConn) stateTLSConn(conn net.Conn) {
 }
 
 func (c *FtpServerConn) makePasv() (host string, port int, err error) {
-	addr := c.conn.RemoteAddr()
-	hostport := addr.String()
-	host, _, err = net.SplitHostPort(hostport)
+	host, err = c.controlHost()
 	if err != nil {
 		return
 	}
 
 	ip := net.ParseIP(host)
-	if ip.To4() != nil {
-		return c.Pasv()
+	if ip.To4() != nil && !c.epsvAllSent {
+		if c.preferEPSV && !c.epsvRejected && c.HasFeature("EPSV") {
+			port, err = c.Epsv()
+			if err == nil || !replycode.IsPermanentNegative(replyCode(err)) {
+				return
+			}
+			c.logf("EPSV rejected, falling back to PASV: %v", err)
+			c.epsvRejected = true
+		}
+
+		var pasvHost string
+		pasvHost, port, err = c.Pasv()
+		if err == nil && c.pasvNAT && pasvHost != host {
+			// servers behind NAT advertise an address the client cannot reach
+			c.logf("ignoring passive address %s, connecting to %s", pasvHost, host)
+			return host, port, nil
+		}
+		return pasvHost, port, err
 	}
 
 	port, err = c.Epsv()
@@ -745,15 +1485,25 @@ func (c *FtpServerConn) makePort() (net.Listener, error) {
 	if err != nil {
 		return nil, err
 	}
+	if c.localAddr != "" {
+		ip, err := c.localIP()
+		if err != nil {
+			return nil, err
+		}
+		host = ip.String()
+	}
 
-	newaddr := net.JoinHostPort(host, "0")
-	listenging := startListen(network, newaddr, c.readWriteTimeout)
-	listener := <-listenging
-	if listener == nil {
-		return nil, errors.New("Unable to create listener")
+	listener, err := c.listenActive(network, host)
+	if err != nil {
+		return nil, err
 	}
 
 	listenerAddr := listener.Addr()
+	if c.externalIP != "" {
+		// advertise the address the server reaches through NAT
+		host = c.externalIP
+		listenerAddr = &net.TCPAddr{IP: net.ParseIP(host), Port: listenerAddr.(*net.TCPAddr).Port}
+	}
 	ip := net.ParseIP(host)
 	if ip.To4() != nil {
 		if err = c.port(listenerAddr); err != nil {
@@ -768,6 +1518,30 @@ func (c *FtpServerConn) makePort() (net.Listener, error) {
 	return listener, err
 }
 
+// listenActive listens for an active data connection on host, on a port of the active
+// port range when one is configured.
+func (c *FtpServerConn) listenActive(network, host string) (net.Listener, error) {
+	timeout := c.timeout(c.acceptTimeout)
+	if c.activePortMin == 0 && c.activePortMax == 0 {
+		listener := <-startListen(network, net.JoinHostPort(host, "0"), timeout)
+		if listener == nil {
+			return nil, errors.New("Unable to create listener")
+		}
+		return listener, nil
+	}
+
+	// start at a random port, so that concurrent sessions rarely collide
+	n := c.activePortMax - c.activePortMin + 1
+	start := rand.Intn(n)
+	for i := 0; i < n; i++ {
+		port := c.activePortMin + (start+i)%n
+		if listener := <-startListen(network, net.JoinHostPort(host, strconv.Itoa(port)), timeout); listener != nil {
+			return listener, nil
+		}
+	}
+	return nil, fmt.Errorf("no free port in active port range %d-%d", c.activePortMin, c.activePortMax)
+}
+
 // startListen
 func startListen(network, laddr string, timeout time.Duration) chan net.Listener {
 	listening := make(chan net.Listener)
@@ -812,9 +1586,18 @@ func parse227(msg string) (host string, port int, err error) {
 	// matches[1] = h1
 	numbers := matches[1:]
 	host = strings.Join(numbers[:4], ".")
+	for _, n := range numbers {
+		if v, _ := strconv.Atoi(n); v > 255 {
+			err = errors.New("Invalid address in message: " + msg)
+			return
+		}
+	}
 	p1, _ := strconv.Atoi(numbers[4])
 	p2, _ := strconv.Atoi(numbers[5])
 	port = (p1 << 8) + p2
+	if port == 0 {
+		err = errors.New("Invalid port in message: " + msg)
+	}
 	return
 }
 
@@ -831,22 +1614,197 @@ func parse257(msg string) (string, error) {
 
 // Read implements the io.Reader interface on a FTP data connection.
 func (d *FtpDataConn) Read(buf []byte) (int, error) {
-	d.conn.SetReadDeadline(time.Now().Add(d.c.readWriteTimeout))
-	return d.conn.Read(buf)
+	d.c.setReadDeadline(d.conn, d.c.timeout(d.c.dataTimeout))
+	if d.stalled() {
+		return 0, d.stallError()
+	}
+	if d.c.downloadLimiter != nil {
+		buf = limitChunk(d.c.downloadLimiter, buf)
+	}
+	n, err := d.conn.Read(buf)
+	d.n += int64(n)
+	d.recordBytes(n)
+	d.countBytes(n)
+	d.traceData(n, false)
+	d.reportProgress(false)
+	if d.c.downloadLimiter != nil && n > 0 {
+		if werr := d.c.downloadLimiter.WaitN(d.c.context(), n); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	if d.watchdog != nil {
+		d.watchdog.add(n)
+		if err != nil && d.stalled() {
+			return n, d.stallError()
+		}
+	}
+	if err != nil && d.c.canceled() {
+		return n, d.c.context().Err()
+	}
+	return n, err
 }
 
 // Write implements the io.Writer interface on a FTP data connection.
+// When the server resets the data connection, Write returns a *DataConnResetError
+// carrying the server reply that explains why.
 func (d *FtpDataConn) Write(buf []byte) (int, error) {
-	d.conn.SetWriteDeadline(time.Now().Add(d.c.readWriteTimeout))
-	return d.conn.Write(buf)
+	if d.c.uploadLimiter == nil {
+		return d.write(buf)
+	}
+
+	// throttled writes go out in chunks no larger than the limiter burst
+	written := 0
+	for written < len(buf) {
+		chunk := limitChunk(d.c.uploadLimiter, buf[written:])
+		if err := d.c.uploadLimiter.WaitN(d.c.context(), len(chunk)); err != nil {
+			return written, err
+		}
+		n, err := d.write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (d *FtpDataConn) write(buf []byte) (int, error) {
+	d.c.setWriteDeadline(d.conn, d.c.timeout(d.c.dataTimeout))
+	if d.stalled() {
+		return 0, d.stallError()
+	}
+	n, err := d.conn.Write(buf)
+	d.n += int64(n)
+	d.recordBytes(n)
+	d.countBytes(n)
+	d.traceData(n, true)
+	d.reportProgress(false)
+	if d.watchdog != nil {
+		d.watchdog.add(n)
+		if err != nil && d.stalled() {
+			return n, d.stallError()
+		}
+	}
+	if err != nil && d.c.canceled() {
+		return n, d.c.context().Err()
+	}
+	if err != nil && !d.replied && isConnReset(err) {
+		d.replied = true
+		code, msg, _ := d.c.getResponse(-1)
+		return n, &DataConnResetError{Code: code, Msg: msg, Err: err}
+	}
+	return n, err
+}
+
+// defaultTransferCompleteCodes are the replies accepted as successful transfer completion.
+var defaultTransferCompleteCodes = []int{ClosingDataConnection, ActionOK, 225}
+
+// isTransferComplete reports whether code completes a transfer successfully.
+func (c *FtpServerConn) isTransferComplete(code int) bool {
+	codes := c.transferCompleteCodes
+	if codes == nil {
+		codes = defaultTransferCompleteCodes
+	}
+	for _, ok := range codes {
+		if code == ok {
+			return true
+		}
+	}
+	return false
 }
 
 // Close implements the io.Closer interface on a FTP data connection.
+// The transfer succeeds when the server replies 226, or 250 or 225 as some servers do;
+// see Config.WithTransferCompleteCodes.
 func (d *FtpDataConn) Close() error {
-	err := d.conn.Close()
-	_, _, err2 := d.c.getResponse(226)
-	if err2 != nil {
-		err = err2
+	if d.closed {
+		return nil
 	}
+	d.closed = true
+	if d.watchdog != nil {
+		d.watchdog.stop()
+	}
+	closeErr := d.conn.Close()
+	var err error
+	if !d.replied && d.stalled() {
+		if err = d.abort(); err == nil {
+			err = d.stallError()
+		}
+	} else if !d.replied && d.aborting {
+		err = d.abort()
+	} else if !d.replied && d.c.canceled() {
+		// the transfer was interrupted by its context
+		err = d.c.resync(d.abort)
+	} else if !d.replied {
+		d.replied = true
+		code, msg, err2 := d.c.getResponse(-1)
+		if err2 == nil && !d.c.isTransferComplete(code) {
+			err2 = &textproto.Error{Code: code, Msg: msg}
+		}
+		if err2 != nil {
+			err = err2
+			if replycode.IsTransientNegative(code) {
+				err = &TransferAbortedError{Code: code, Msg: msg, Bytes: d.n}
+			}
+		}
+		d.reply = msg
+	}
+	if err == nil {
+		err = closeErr
+	} else {
+		d.c.secondary(&err, closeErr)
+	}
+	if err == nil {
+		d.reportProgress(true)
+	}
+	if d.stop != nil {
+		if err2 := d.stop(); err2 != nil {
+			if err == nil || !d.c.strictErrors {
+				err = err2
+			} else {
+				err = errors.Join(err, err2)
+			}
+		}
+	}
+	d.observeClose(err)
 	return err
 }
+
+// DataConnResetError is returned when the server closed the data connection during
+// an upload, typically because a quota or permission check failed.
+// Code and Msg hold the server reply, and are empty if no reply could be read.
+type DataConnResetError struct {
+	Code int
+	Msg  string
+	Err  error
+}
+
+func (e *DataConnResetError) Error() string {
+	if e.Code == 0 {
+		return "data connection reset by server: " + e.Err.Error()
+	}
+	return "data connection reset by server: " + strconv.Itoa(e.Code) + " " + e.Msg
+}
+
+// Unwrap returns the underlying network error.
+func (e *DataConnResetError) Unwrap() error {
+	return e.Err
+}
+
+// TransferAbortedError is returned when the server completes a transfer with a
+// transient negative reply such as 426 or 451 instead of 226. Bytes is the number of
+// bytes that went through the data connection, which resume logic can use.
+type TransferAbortedError struct {
+	Code  int
+	Msg   string
+	Bytes int64
+}
+
+func (e *TransferAbortedError) Error() string {
+	return fmt.Sprintf("transfer aborted by server after %d bytes: %d %s", e.Bytes, e.Code, e.Msg)
+}
+
+// isConnReset reports whether err means that the peer closed the connection.
+func isConnReset(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
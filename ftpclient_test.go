@@ -1,12 +1,16 @@
 package ftpclient
 
 import (
+	"bytes"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -453,7 +457,7 @@ func dir(host string, port int, user, pass string, passive, tlsenable bool, cert
 		return err
 	}
 
-	// err = client.Feat()
+	// _, err = client.Feat()
 	// if err != nil {
 	// 	return err
 	// }
@@ -475,3 +479,212 @@ func dir(host string, port int, user, pass string, passive, tlsenable bool, cert
 
 	return nil
 }
+
+func TestListenActivePortRange(t *testing.T) {
+	// find a free port, assuming its neighbour is free as well
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	c := New(NewConfig().WithActivePortRange(port, port+1))
+	first, err := c.listenActive("tcp", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := c.listenActive("tcp", "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	ports := map[int]bool{
+		first.Addr().(*net.TCPAddr).Port:  true,
+		second.Addr().(*net.TCPAddr).Port: true,
+	}
+	if !ports[port] || !ports[port+1] {
+		t.Errorf("listened on %v, want %d and %d", ports, port, port+1)
+	}
+
+	if third, err := c.listenActive("tcp", "127.0.0.1"); err == nil {
+		third.Close()
+		t.Error("listened outside of the exhausted range")
+	}
+}
+
+func TestPreferEPSV(t *testing.T) {
+	// go test -v -run TestPreferEPSV
+	cases := []struct {
+		Name   string
+		Prefer bool
+		Feat   string // features listed by FEAT
+		Epsv   string // reply to EPSV, a passive listener when empty
+		Sent   int    // EPSV commands sent for two transfers
+	}{
+		{"epsv", true, "EPSV", "", 2},
+		{"rejected", true, "EPSV", "500 unknown command", 1},
+		{"not listed", true, "MDTM", "", 0},
+		{"disabled", false, "EPSV", "", 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr, fs := memServer(t, map[string]string{"file": "data"}, func(s *testSession, cmd string) bool {
+				switch cmd {
+				case "FEAT":
+					s.reply("211-Features:\r\n %s\r\n211 End", tc.Feat)
+				case "EPSV":
+					if tc.Epsv != "" {
+						s.reply(tc.Epsv)
+						return true
+					}
+					var err error
+					if s.data, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+						s.reply("425 no listener")
+						return true
+					}
+					s.reply("229 Entering Extended Passive Mode (|||%d|)", s.data.Addr().(*net.TCPAddr).Port)
+				default:
+					return false
+				}
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig().WithPreferEPSV(tc.Prefer))
+
+			for i := 0; i < 2; i++ {
+				var buf strings.Builder
+				if _, err := c.RetrTo("file", &buf, 0); err != nil || buf.String() != "data" {
+					t.Fatalf("RetrTo() = %q, %v", buf.String(), err)
+				}
+			}
+			if sent := len(fs.commands("EPSV")); sent != tc.Sent {
+				t.Errorf("sent EPSV %d times, want %d", sent, tc.Sent)
+			}
+		})
+	}
+}
+
+func TestDataConnReset(t *testing.T) {
+	// go test -v -run TestDataConnReset
+	addr, _ := memServer(t, nil, func(s *testSession, cmd string) bool {
+		if !strings.HasPrefix(cmd, "STOR ") {
+			return false
+		}
+		s.reply("150 opening")
+		conn, err := s.accept()
+		if err != nil {
+			return true
+		}
+		// refuse the upload after its first bytes and reset the connection
+		io.ReadFull(conn, make([]byte, 1024))
+		conn.(*net.TCPConn).SetLinger(0)
+		conn.Close()
+		s.reply("552 quota exceeded")
+		return true
+	})
+	c := dialTestServer(t, addr, NewConfig())
+
+	_, err := c.StorFrom("file", bytes.NewReader(make([]byte, 64<<20)), 0)
+	var resetErr *DataConnResetError
+	if !errors.As(err, &resetErr) {
+		t.Fatalf("err = %v, want a DataConnResetError", err)
+	}
+	if resetErr.Code != 552 || resetErr.Msg != "quota exceeded" {
+		t.Errorf("reply = %d %s, want 552 quota exceeded", resetErr.Code, resetErr.Msg)
+	}
+
+	// the reply was consumed with the error
+	if err = c.Noop(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransferAborted(t *testing.T) {
+	// go test -v -run TestTransferAborted
+	cases := []struct {
+		Name    string
+		Reply   string // final reply to RETR
+		Aborted bool
+	}{
+		{"426", "426 connection closed; transfer aborted", true},
+		{"451", "451 local error", true},
+		{"551", "551 page type unknown", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr, _ := memServer(t, nil, func(s *testSession, cmd string) bool {
+				if !strings.HasPrefix(cmd, "RETR ") {
+					return false
+				}
+				s.reply("150 opening")
+				conn, err := s.accept()
+				if err != nil {
+					return true
+				}
+				io.WriteString(conn, "partial")
+				conn.Close()
+				s.reply(tc.Reply)
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig())
+
+			_, err := c.RetrTo("file", io.Discard, 0)
+			var abortErr *TransferAbortedError
+			if errors.As(err, &abortErr) != tc.Aborted {
+				t.Fatalf("err = %v, aborted = %v", err, !tc.Aborted)
+			}
+			if tc.Aborted && abortErr.Bytes != int64(len("partial")) {
+				t.Errorf("bytes = %d, want %d", abortErr.Bytes, len("partial"))
+			}
+			if !tc.Aborted && !isReply(err, 551) {
+				t.Errorf("err = %v, want the 551 reply", err)
+			}
+		})
+	}
+}
+
+func TestDialResetsSessionState(t *testing.T) {
+	// go test -v -run TestDialResetsSessionState
+	var mu sync.Mutex
+	feats := 0
+	addr := testServer(t, func(s *testSession, cmd string) bool {
+		switch cmd {
+		case "FEAT":
+			mu.Lock()
+			feats++
+			mu.Unlock()
+			s.reply("211-Features:\r\n EPSV\r\n211 End")
+		case "EPSV ALL":
+			s.reply("229 EPSV ALL ok")
+		default:
+			return false
+		}
+		return true
+	})
+	c := dialTestServer(t, addr, NewConfig())
+	if !c.HasFeature("EPSV") {
+		t.Fatal("EPSV is not listed")
+	}
+	if err := c.EpsvAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new connection negotiates with the server again
+	c.Quit()
+	if err := c.Dial(addr); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Port("127.0.0.1", 2121); err == ErrEpsvAll {
+		t.Error("EPSV ALL carried over to the new connection")
+	}
+	c.HasFeature("EPSV")
+	mu.Lock()
+	defer mu.Unlock()
+	if feats != 2 {
+		t.Errorf("sent FEAT %d times, want 2", feats)
+	}
+}
@@ -7,17 +7,72 @@ import (
 
 // Config ...
 type Config struct {
-	tlsConfig        *tls.Config
-	tlsImplicit      bool
-	logger           Logger
-	readWriteTimeout time.Duration
+	tlsConfig             *tls.Config
+	tlsImplicit           bool
+	logger                Logger
+	readWriteTimeout      time.Duration
+	preallocate           bool
+	bufferSize            int
+	replyPolicy           ReplyPolicy
+	epsvAll               bool
+	passive               bool
+	proxyHeader           *ProxyHeader
+	tempNamer             TempNamer
+	checkpointEvery       int64
+	checkpoint            CheckpointFunc
+	tlsSessionBinding     bool
+	circuitBreaker        *CircuitBreaker
+	retryPolicy           RetryPolicy
+	resumePolicy          *ResumePolicy
+	rfc2640               bool
+	stallPeriod           time.Duration
+	stallMinBytes         int64
+	keepAlive             time.Duration
+	keepAliveTransfers    bool
+	nameMapper            NameMapper
+	downloadLimiter       RateLimiter
+	uploadLimiter         RateLimiter
+	markerNamer           MarkerNamer
+	smallFileThreshold    int64
+	progress              ProgressFunc
+	transferCompleteCodes []int
+	metrics               Metrics
+	charset               *Charset
+	charsetCandidates     []Charset
+	logLevel              Level
+	tracer                Tracer
+	trashDir              string
+	virtualHost           string
+	tempDir               string
+	strictErrors          bool
+	protLevel             ProtectionLevel
+	dialer                Dialer
+	localAddr             string
+	externalIP            string
+	pasvNAT               bool
+	preferEPSV            bool
+	verifyPeer            bool
+	connectTimeout        time.Duration
+	commandTimeout        time.Duration
+	dataTimeout           time.Duration
+	acceptTimeout         time.Duration
+	transferDeadline      time.Duration
+	listParsers           []ListParser
+	serverLocation        *time.Location
+	followSymlinks        bool
+	serverGlob            bool
+	activePortMin         int
+	activePortMax         int
 }
 
 // NewConfig ...
 func NewConfig() *Config {
 	return &Config{
-		tlsImplicit:      false,
-		readWriteTimeout: 120 * time.Second,
+		tlsImplicit:       false,
+		readWriteTimeout:  120 * time.Second,
+		bufferSize:        32 * 1024,
+		charsetCandidates: DefaultCharsetCandidates,
+		preferEPSV:        true,
 	}
 }
 
@@ -40,7 +95,410 @@ func (c *Config) WithTLSImplicit(implicit bool) *Config {
 }
 
 // WithReadWriteTimeout sets a config ReadWriteTimeout value returning a Config pointer for chaining.
+// It is the default of the command, data, accept and passive dial timeouts.
 func (c *Config) WithReadWriteTimeout(time time.Duration) *Config {
 	c.readWriteTimeout = time
 	return c
 }
+
+// WithPreallocate sets a config preallocate value returning a Config pointer for chaining.
+// When enabled, RetrFile truncates the local file to the remote SIZE before downloading.
+func (c *Config) WithPreallocate(preallocate bool) *Config {
+	c.preallocate = preallocate
+	return c
+}
+
+// WithBufferSize sets a config bufferSize value returning a Config pointer for chaining.
+// The size is rounded up to a multiple of 4096 bytes so local writes stay block aligned.
+func (c *Config) WithBufferSize(size int) *Config {
+	const align = 4096
+	if size <= 0 {
+		size = 32 * 1024
+	}
+	c.bufferSize = (size + align - 1) / align * align
+	return c
+}
+
+// WithReplyPolicy sets a config replyPolicy value returning a Config pointer for chaining.
+func (c *Config) WithReplyPolicy(policy ReplyPolicy) *Config {
+	c.replyPolicy = policy
+	return c
+}
+
+// WithEpsvAll sets a config epsvAll value returning a Config pointer for chaining.
+// When enabled, EPSV ALL is sent after login and only EPSV is used for data connections.
+func (c *Config) WithEpsvAll(epsvAll bool) *Config {
+	c.epsvAll = epsvAll
+	return c
+}
+
+// WithPassive sets a config passive value returning a Config pointer for chaining.
+// It is the initial data connection mode of connections created by New.
+func (c *Config) WithPassive(passive bool) *Config {
+	c.passive = passive
+	return c
+}
+
+// WithProxyHeader sets a config proxyHeader value returning a Config pointer for chaining.
+// The header is sent on the control connection before any other data.
+func (c *Config) WithProxyHeader(header *ProxyHeader) *Config {
+	c.proxyHeader = header
+	return c
+}
+
+// WithTempNamer sets a config tempNamer value returning a Config pointer for chaining.
+func (c *Config) WithTempNamer(namer TempNamer) *Config {
+	c.tempNamer = namer
+	return c
+}
+
+// WithCheckpoint sets config checkpoint values returning a Config pointer for chaining.
+// RetrTo calls fn every time another every bytes have been transferred; StorFrom calls
+// it once the server confirmed the upload.
+func (c *Config) WithCheckpoint(every int64, fn CheckpointFunc) *Config {
+	c.checkpointEvery = every
+	c.checkpoint = fn
+	return c
+}
+
+// WithTLSSessionBinding sets a config tlsSessionBinding value returning a Config pointer for chaining.
+// When enabled, passive data connections must resume the TLS session of the control
+// connection and present the same server certificate. Session resumption requires
+// a ClientSessionCache in the tls.Config.
+func (c *Config) WithTLSSessionBinding(binding bool) *Config {
+	c.tlsSessionBinding = binding
+	return c
+}
+
+// WithCircuitBreaker sets a config circuitBreaker value returning a Config pointer for chaining.
+// Dial fails fast with ErrCircuitOpen while the circuit of the server address is open.
+func (c *Config) WithCircuitBreaker(breaker *CircuitBreaker) *Config {
+	c.circuitBreaker = breaker
+	return c
+}
+
+// WithRetryPolicy sets a config retryPolicy value returning a Config pointer for chaining.
+// Login, Dir, Fxp and the file transfer helpers retry according to it; DefaultRetryPolicy
+// retries transient failures with exponential backoff.
+func (c *Config) WithRetryPolicy(policy RetryPolicy) *Config {
+	c.retryPolicy = policy
+	return c
+}
+
+// WithResumePolicy sets a config resumePolicy value returning a Config pointer for chaining.
+func (c *Config) WithResumePolicy(policy *ResumePolicy) *Config {
+	c.resumePolicy = policy
+	return c
+}
+
+// WithRFC2640 sets a config rfc2640 value returning a Config pointer for chaining.
+// When enabled, pathnames are encoded as RFC 2640 specifies: IAC is doubled and CR is
+// sent as CR NUL. Quoted names in 257 replies and names in listings are decoded.
+func (c *Config) WithRFC2640(strict bool) *Config {
+	c.rfc2640 = strict
+	return c
+}
+
+// WithStallWatchdog sets a config stall watchdog returning a Config pointer for chaining.
+// A transfer moving fewer than minBytes during any period is aborted with ABOR and
+// fails with a *StalledTransferError. A zero period disables the watchdog.
+func (c *Config) WithStallWatchdog(period time.Duration, minBytes int64) *Config {
+	c.stallPeriod = period
+	if minBytes < 1 {
+		minBytes = 1
+	}
+	c.stallMinBytes = minBytes
+	return c
+}
+
+// WithKeepAlive sets a config keepAlive value returning a Config pointer for chaining.
+// NOOP is sent on the control connection once it has been idle for the given period.
+// With duringTransfers, NOOP is also sent while a long transfer keeps the control
+// connection silent; only enable it for servers that accept commands during transfers.
+func (c *Config) WithKeepAlive(idle time.Duration, duringTransfers bool) *Config {
+	c.keepAlive = idle
+	c.keepAliveTransfers = duringTransfers
+	return c
+}
+
+// WithNameMapper sets a config nameMapper value returning a Config pointer for chaining.
+// StorFile and UploadDir pass the names they create on the server through it.
+func (c *Config) WithNameMapper(mapper NameMapper) *Config {
+	c.nameMapper = mapper
+	return c
+}
+
+// WithMaxDownloadRate sets a config download limit in bytes per second returning a
+// Config pointer for chaining. The limit is shared by all connections using the Config.
+func (c *Config) WithMaxDownloadRate(bytesPerSecond int) *Config {
+	c.downloadLimiter = NewRateLimiter(bytesPerSecond)
+	return c
+}
+
+// WithMaxUploadRate sets a config upload limit in bytes per second returning a
+// Config pointer for chaining. The limit is shared by all connections using the Config.
+func (c *Config) WithMaxUploadRate(bytesPerSecond int) *Config {
+	c.uploadLimiter = NewRateLimiter(bytesPerSecond)
+	return c
+}
+
+// WithDownloadLimiter sets a config downloadLimiter value returning a Config pointer for chaining.
+func (c *Config) WithDownloadLimiter(limiter RateLimiter) *Config {
+	c.downloadLimiter = limiter
+	return c
+}
+
+// WithUploadLimiter sets a config uploadLimiter value returning a Config pointer for chaining.
+func (c *Config) WithUploadLimiter(limiter RateLimiter) *Config {
+	c.uploadLimiter = limiter
+	return c
+}
+
+// WithMarker sets a config markerNamer value returning a Config pointer for chaining.
+// StorFile, UploadDir and WithTransactionalRename then verify each upload with SIZE
+// and write an empty marker file named by namer once it succeeded.
+func (c *Config) WithMarker(namer MarkerNamer) *Config {
+	c.markerNamer = namer
+	return c
+}
+
+// WithSmallFileThreshold sets a config smallFileThreshold value returning a Config pointer for chaining.
+// Files up to threshold bytes are transferred through pooled in-memory buffers and a
+// single write, which saves system calls when moving many tiny files. The threshold
+// bounds the memory held per transfer; zero disables the fast path.
+func (c *Config) WithSmallFileThreshold(threshold int64) *Config {
+	c.smallFileThreshold = threshold
+	return c
+}
+
+// WithProgress sets a config progress value returning a Config pointer for chaining.
+// RetrFile, StorFile and the readers and writers returned by the request methods
+// call fn as data moves. Listings do not report progress.
+func (c *Config) WithProgress(fn ProgressFunc) *Config {
+	c.progress = fn
+	return c
+}
+
+// WithTransferCompleteCodes sets a config transferCompleteCodes value returning a Config pointer for chaining.
+// They are the replies accepted as successful completion of a transfer, by default
+// 226, 250 and 225. Use a ProfileStore to set them for specific servers.
+func (c *Config) WithTransferCompleteCodes(codes ...int) *Config {
+	c.transferCompleteCodes = codes
+	return c
+}
+
+// WithMetrics sets a config metrics value returning a Config pointer for chaining.
+// The Metrics receive reply, transfer and data connection events.
+func (c *Config) WithMetrics(metrics Metrics) *Config {
+	c.metrics = metrics
+	return c
+}
+
+// WithCharset sets a config charset value returning a Config pointer for chaining.
+// Commands are then encoded in cs, and listings and replies decoded from it, instead
+// of sniffing the charset. Use CharsetByName for charsets such as EUC-KR or cp1252.
+func (c *Config) WithCharset(cs Charset) *Config {
+	c.charset = &cs
+	return c
+}
+
+// WithCharsetCandidates sets a config charsetCandidates value returning a Config pointer for chaining.
+// When no charset is configured, the first candidate decoding non-ASCII listing data
+// becomes the charset of the session. The default is DefaultCharsetCandidates; no
+// candidates disable sniffing.
+func (c *Config) WithCharsetCandidates(candidates ...Charset) *Config {
+	c.charsetCandidates = candidates
+	return c
+}
+
+// WithLogLevel sets a config logLevel value returning a Config pointer for chaining.
+// Messages below level are not logged. The default, LevelDebug, logs the wire protocol.
+func (c *Config) WithLogLevel(level Level) *Config {
+	c.logLevel = level
+	return c
+}
+
+// WithTracer sets a config tracer value returning a Config pointer for chaining.
+// The Tracer receives every command, reply and data byte count of the session.
+func (c *Config) WithTracer(tracer Tracer) *Config {
+	c.tracer = tracer
+	return c
+}
+
+// WithTrash sets a config trashDir value returning a Config pointer for chaining.
+// Delete and RemoveAll then move their targets into dir with RNFR and RNTO instead of
+// removing them, giving an undo window; PurgeTrash empties it.
+func (c *Config) WithTrash(dir string) *Config {
+	c.trashDir = dir
+	return c
+}
+
+// WithHost sets a config virtualHost value returning a Config pointer for chaining.
+// The name is sent with HOST (RFC 7151) before logging in, so that a virtual host
+// sharing the address of others can be addressed.
+func (c *Config) WithHost(name string) *Config {
+	c.virtualHost = name
+	return c
+}
+
+// WithTempDir sets a config tempDir value returning a Config pointer for chaining.
+// Upload temporaries, such as those of a Transaction, are then staged in a directory
+// of their own below dir, created on demand and removed by Quit or CleanupTempDir.
+func (c *Config) WithTempDir(dir string) *Config {
+	c.tempDir = dir
+	return c
+}
+
+// WithStrictErrors sets a config strictErrors value returning a Config pointer for chaining.
+// When enabled, secondary failures otherwise ignored, such as a failed QUIT, an error
+// closing a data connection or the local file of a download, are joined to the
+// returned error with errors.Join.
+func (c *Config) WithStrictErrors(strict bool) *Config {
+	c.strictErrors = strict
+	return c
+}
+
+// WithProtLevel sets a config protLevel value returning a Config pointer for chaining.
+// It is the data connection protection level sent with PROT after logging in over
+// TLS; ProtClear keeps data connections unencrypted. The default is ProtPrivate.
+func (c *Config) WithProtLevel(level ProtectionLevel) *Config {
+	c.protLevel = level
+	return c
+}
+
+// WithDialer sets a config dialer value returning a Config pointer for chaining.
+// The dialer opens the control connection and passive data connections in place of
+// a net.Dialer, e.g. to route them, set socket options or connect to a Unix socket.
+func (c *Config) WithDialer(dialer Dialer) *Config {
+	c.dialer = dialer
+	return c
+}
+
+// WithLocalAddr sets a config localAddr value returning a Config pointer for chaining.
+// addr is an IP address or a network interface name; the control and passive data
+// connections are dialed from it and active mode listens on it, for multi-homed
+// hosts whose server requires data connections from the control connection address.
+func (c *Config) WithLocalAddr(addr string) *Config {
+	c.localAddr = addr
+	return c
+}
+
+// WithExternalIP sets a config externalIP value returning a Config pointer for chaining.
+// Active mode then advertises ip in PORT and EPRT instead of the local address of the
+// control connection, which is private behind NAT. The listening port must be
+// forwarded to this host.
+func (c *Config) WithExternalIP(ip string) *Config {
+	c.externalIP = ip
+	return c
+}
+
+// WithPassiveNAT sets a config pasvNAT value returning a Config pointer for chaining.
+// Passive data connections are then dialed to the control connection host whenever
+// the 227 reply advertises another address, as servers behind NAT advertise their
+// unreachable internal address.
+func (c *Config) WithPassiveNAT(enabled bool) *Config {
+	c.pasvNAT = enabled
+	return c
+}
+
+// WithPreferEPSV sets a config preferEPSV value returning a Config pointer for chaining.
+// When enabled, the default, passive data connections over IPv4 are opened with EPSV
+// if FEAT lists it, avoiding the address in the 227 reply, and with PASV once EPSV is
+// rejected. Otherwise PASV is used over IPv4.
+func (c *Config) WithPreferEPSV(prefer bool) *Config {
+	c.preferEPSV = prefer
+	return c
+}
+
+// WithVerifyDataPeer sets a config verifyPeer value returning a Config pointer for chaining.
+// Data connections are then rejected with ErrDataPeerMismatch unless their remote IP
+// is that of the control connection, protecting against FTP bounce and data
+// connection hijacking. It is incompatible with servers using a separate data host.
+func (c *Config) WithVerifyDataPeer(verify bool) *Config {
+	c.verifyPeer = verify
+	return c
+}
+
+// WithConnectTimeout sets a config connectTimeout value returning a Config pointer for chaining.
+// It bounds dialing the control connection when Dial is used without a timeout, and
+// passive data connections, for which it defaults to the read/write timeout.
+func (c *Config) WithConnectTimeout(timeout time.Duration) *Config {
+	c.connectTimeout = timeout
+	return c
+}
+
+// WithCommandTimeout sets a config commandTimeout value returning a Config pointer for chaining.
+// It bounds sending a command and reading its reply on the control connection, and
+// defaults to the read/write timeout.
+func (c *Config) WithCommandTimeout(timeout time.Duration) *Config {
+	c.commandTimeout = timeout
+	return c
+}
+
+// WithDataTimeout sets a config dataTimeout value returning a Config pointer for chaining.
+// It bounds each Read and Write of a data connection, not the whole transfer, and
+// defaults to the read/write timeout.
+func (c *Config) WithDataTimeout(timeout time.Duration) *Config {
+	c.dataTimeout = timeout
+	return c
+}
+
+// WithAcceptTimeout sets a config acceptTimeout value returning a Config pointer for chaining.
+// It bounds waiting for the server to open an active mode data connection, and
+// defaults to the read/write timeout.
+func (c *Config) WithAcceptTimeout(timeout time.Duration) *Config {
+	c.acceptTimeout = timeout
+	return c
+}
+
+// WithTransferDeadline sets a config transferDeadline value returning a Config pointer for chaining.
+// A transfer still running after deadline is aborted with ABOR and fails with a
+// *TransferDeadlineError, however steadily data flows. Retries get a new deadline.
+func (c *Config) WithTransferDeadline(deadline time.Duration) *Config {
+	c.transferDeadline = deadline
+	return c
+}
+
+// WithListParsers sets a config listParsers value returning a Config pointer for chaining.
+// LIST lines are then parsed only by parsers, tried in order, instead of the
+// registered and built-in parsers; restricting a session to the one format its
+// server uses, e.g. UnixListParser, saves trying the others.
+func (c *Config) WithListParsers(parsers ...ListParser) *Config {
+	c.listParsers = parsers
+	return c
+}
+
+// WithServerLocation sets a config serverLocation value returning a Config pointer for chaining.
+// LIST timestamps carry no timezone; they are read as wall clock times in loc and
+// converted to UTC. The default reads them as UTC. DetectServerLocation can
+// determine the timezone from the server instead.
+func (c *Config) WithServerLocation(loc *time.Location) *Config {
+	c.serverLocation = loc
+	return c
+}
+
+// WithFollowSymlinks sets a config followSymlinks value returning a Config pointer for chaining.
+// Walk, and so DownloadDir, then resolves symbolic links with ResolveSymlink and
+// descends into links to directories.
+func (c *Config) WithFollowSymlinks(follow bool) *Config {
+	c.followSymlinks = follow
+	return c
+}
+
+// WithServerGlob sets a config serverGlob value returning a Config pointer for chaining.
+// Glob then passes the last element of patterns to NLST, letting the server expand
+// the wildcards instead of listing the whole directory, and falls back to listing
+// when NLST is refused.
+func (c *Config) WithServerGlob(enabled bool) *Config {
+	c.serverGlob = enabled
+	return c
+}
+
+// WithActivePortRange sets a config active port range returning a Config pointer for chaining.
+// Active data connections listen on a port between min and max inclusive, so that a
+// client firewall only needs to open that range. Zero values listen on any port.
+func (c *Config) WithActivePortRange(min, max int) *Config {
+	c.activePortMin = min
+	c.activePortMax = max
+	return c
+}
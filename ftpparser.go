@@ -16,6 +16,12 @@ type fileInfo struct {
 	mode  os.FileMode
 	mtime time.Time
 	raw   string
+	// local reports that mtime is the server's wall clock read as UTC
+	local  bool
+	owner  string
+	group  string
+	target string
+	facts  map[string]string
 }
 
 func (f fileInfo) Name() string {
@@ -51,24 +57,33 @@ func (f fileInfo) MarshalJSON() ([]byte, error) {
 	})
 }
 
-var errUnknownFormat = errors.New("Unknown format")
+// ErrUnknownFormat is returned by a ListParser for lines not in its format.
+var ErrUnknownFormat = errors.New("Unknown format")
 
-var formatParsers = []func(line string) (os.FileInfo, error){
+// formatParsers are the built-in parsers tried by default. The z/OS formats are
+// loose enough to match stray lines of other servers and are left out.
+var formatParsers = []ListParser{
+	parseMlsxFormat,
 	parseUnixFormat,
 	parseDosFormat,
 }
 
 // Parse response string
 func parse(line string) (os.FileInfo, error) {
+	return parseWith(listParsers(), line)
+}
+
+// parseWith parses line with the first of parsers recognizing its format.
+func parseWith(parsers []ListParser, line string) (os.FileInfo, error) {
 	//log.Println("parse", line)
-	for _, f := range formatParsers {
+	for _, f := range parsers {
 		fileInfo, err := f(line)
-		if err == errUnknownFormat {
+		if err == ErrUnknownFormat {
 			continue
 		}
 		return fileInfo, err
 	}
-	return nil, errUnknownFormat
+	return nil, ErrUnknownFormat
 }
 
 func parseDosDateTime(input string) (dateTime time.Time, err error) {
@@ -82,10 +97,13 @@ func parseDosDateTime(input string) (dateTime time.Time, err error) {
 }
 
 func parseDosFormat(input string) (os.FileInfo, error) {
+	if len(input) < 17 {
+		return nil, ErrUnknownFormat
+	}
 	value := input[:17]
 	mtime, err := parseDosDateTime(value)
 	if err != nil {
-		return nil, errUnknownFormat
+		return nil, ErrUnknownFormat
 	}
 
 	var size uint64
@@ -99,11 +117,11 @@ func parseDosFormat(input string) (os.FileInfo, error) {
 	} else {
 		space := strings.Index(value, " ")
 		if space == -1 {
-			return nil, errUnknownFormat
+			return nil, ErrUnknownFormat
 		}
 		size, err = strconv.ParseUint(value[:space], 10, 64)
 		if err != nil {
-			return nil, errUnknownFormat
+			return nil, ErrUnknownFormat
 		}
 
 		value = value[space:]
@@ -116,12 +134,19 @@ func parseDosFormat(input string) (os.FileInfo, error) {
 		mode:  mode,
 		mtime: mtime,
 		raw:   input,
+		local: true,
 	}
 
 	return f, nil
 }
 
 func parseUnixFormat(input string) (os.FileInfo, error) {
+	return parseUnixFormatAt(input, time.Now())
+}
+
+// parseUnixFormatAt parses a line of a Unix listing, taking dates without a year to
+// be in the year of now.
+func parseUnixFormatAt(input string, now time.Time) (os.FileInfo, error) {
 	var err error
 	var name string
 	var size uint64
@@ -129,9 +154,9 @@ func parseUnixFormat(input string) (os.FileInfo, error) {
 	var mtime time.Time
 
 	fields := strings.Fields(input)
-	if len(fields) < 9 {
+	if len(fields) < 8 || len(fields[0]) < 10 {
 		//log.Println("parseUnixFormat#1 ", len(fields))
-		return nil, errUnknownFormat
+		return nil, ErrUnknownFormat
 	}
 
 	// type
@@ -172,38 +197,291 @@ func parseUnixFormat(input string) (os.FileInfo, error) {
 	}
 
 	// datetime
-	mtime, err = parseDateTime(fields[5:8])
+	nameAt, local := 8, true
+	if isISODate(fields[5]) {
+		mtime, nameAt, err = parseISODateTime(fields[5:])
+		local = nameAt == 2
+		nameAt += 5
+	} else if len(fields) < 9 {
+		return nil, ErrUnknownFormat
+	} else {
+		mtime, err = parseDateTime(fields[5:8], now)
+	}
 	if err != nil {
 		//log.Println("parseUnixFormat#3", err.Error())
 		return nil, err
 	}
+	if nameAt >= len(fields) {
+		return nil, ErrUnknownFormat
+	}
 
 	// name
-	name = strings.Join(fields[8:], " ")
+	name = strings.Join(fields[nameAt:], " ")
+	var target string
+	if mode&os.ModeSymlink != 0 {
+		if i := strings.Index(name, " -> "); i != -1 {
+			name, target = name[:i], name[i+4:]
+		}
+	}
 
 	f := &fileInfo{
-		name:  name,
-		size:  int64(size),
-		mode:  mode,
-		mtime: mtime,
-		raw:   input,
+		name:   name,
+		size:   int64(size),
+		mode:   mode,
+		mtime:  mtime,
+		raw:    input,
+		local:  local,
+		owner:  fields[2],
+		group:  fields[3],
+		target: target,
 	}
 
 	return f, nil
 }
 
-func parseDateTime(fields []string) (mtime time.Time, err error) {
-	var value string
-	if strings.Contains(fields[2], ":") {
-		thisYear, _, _ := time.Now().Date()
-		value = fields[1] + " " + fields[0] + " " + strconv.Itoa(thisYear)[2:4] + " " + fields[2] + " GMT"
-	} else {
-		if len(fields[2]) != 4 {
-			return mtime, errors.New("Invalid year format in time string")
+// parseDateTime parses the month, day and time or year of a Unix listing, as in
+// "Jan 2 15:04" or "Jan 2 2006". The month and day may come in either order and
+// the month name may be localized, as in "2. Mär 2006", "2 janv. 15:04" or "1月 2日 15:04".
+// Dates given with a time instead of a year are taken to be in the year of now.
+func parseDateTime(fields []string, now time.Time) (mtime time.Time, err error) {
+	month, day, ok := parseMonthDay(fields[0], fields[1])
+	if !ok {
+		month, day, ok = parseMonthDay(fields[1], fields[0])
+	}
+	if !ok {
+		return mtime, errors.New("Invalid date format in time string")
+	}
+
+	value := strings.TrimSuffix(fields[2], "年")
+	if strings.Contains(value, ":") {
+		clock, err := time.Parse("15:04", value)
+		if err != nil {
+			return mtime, err
+		}
+		return time.Date(now.Year(), month, day, clock.Hour(), clock.Minute(), 0, 0, time.UTC), nil
+	}
+	if len(value) != 4 {
+		return mtime, errors.New("Invalid year format in time string")
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil {
+		return mtime, err
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+}
+
+// monthNames maps abbreviated month names of common ls locales to months.
+var monthNames = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+	// German
+	"mär": time.March, "mrz": time.March, "mai": time.May, "okt": time.October, "dez": time.December,
+	// French
+	"janv": time.January, "févr": time.February, "fevr": time.February,
+	"mars": time.March, "avr": time.April, "juin": time.June, "juil": time.July,
+	"août": time.August, "aout": time.August, "sept": time.September, "déc": time.December,
+	// Spanish and Italian
+	"ene": time.January, "abr": time.April, "ago": time.August, "dic": time.December,
+	"gen": time.January, "mag": time.May, "giu": time.June, "lug": time.July, "set": time.September, "ott": time.October,
+}
+
+// parseMonth parses a month name, or a number followed by 月 or 월 as in CJK locales.
+func parseMonth(s string) (time.Month, bool) {
+	for _, suffix := range []string{"月", "월"} {
+		if n := strings.TrimSuffix(s, suffix); n != s {
+			m, err := strconv.Atoi(n)
+			return time.Month(m), err == nil && m >= 1 && m <= 12
 		}
-		value = fields[1] + " " + fields[0] + " " + fields[2][2:4] + " 00:00 GMT"
 	}
 
-	mtime, err = time.Parse("_2 Jan 06 15:04 MST", value)
-	return
+	name := strings.ToLower(strings.TrimSuffix(s, "."))
+	if m, ok := monthNames[name]; ok {
+		return m, true
+	}
+	// full English names
+	if len(name) > 3 && isLetters(name) {
+		m, ok := monthNames[name[:3]]
+		return m, ok
+	}
+	return 0, false
+}
+
+// parseMonthDay parses a month name and a day of the month, such as "2", "2." or "2日".
+func parseMonthDay(monthField, dayField string) (time.Month, int, bool) {
+	month, ok := parseMonth(monthField)
+	if !ok {
+		return 0, 0, false
+	}
+	day, err := strconv.Atoi(strings.TrimRight(dayField, ".日일"))
+	if err != nil || day < 1 || day > 31 {
+		return 0, 0, false
+	}
+	return month, day, true
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// isISODate reports whether s is a date like "2006-01-02", as listed by
+// ls --time-style=long-iso or full-iso.
+func isISODate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
+
+// parseISODateTime parses "2006-01-02 15:04" or "2006-01-02 15:04:05.999999999 -0700"
+// from the start of fields, returning the number of fields used.
+func parseISODateTime(fields []string) (time.Time, int, error) {
+	if len(fields) < 2 {
+		return time.Time{}, 0, ErrUnknownFormat
+	}
+	value := fields[0] + " " + fields[1]
+	if len(fields) > 2 && len(fields[2]) == 5 && (fields[2][0] == '+' || fields[2][0] == '-') {
+		mtime, err := time.Parse("2006-01-02 15:04:05.999999999 -0700", value+" "+fields[2])
+		return mtime.UTC(), 3, err
+	}
+	if mtime, err := time.Parse("2006-01-02 15:04", value); err == nil {
+		return mtime, 2, nil
+	}
+	mtime, err := time.Parse("2006-01-02 15:04:05.999999999", value)
+	return mtime, 2, err
+}
+
+// MlsEntry describes a file by the machine-readable facts of RFC 3659,
+// as returned by MLSD and MLST. Fact names in Facts are lower case.
+type MlsEntry struct {
+	Name   string
+	Type   string
+	Size   int64
+	Modify time.Time
+	Perm   string
+	Unique string
+	Facts  map[string]string
+	raw    string
+}
+
+// parseMlsxLine parses a MLSD/MLST fact line such as
+// "type=file;size=1024;modify=20200102150405; name".
+func parseMlsxLine(line string) (*MlsEntry, error) {
+	space := strings.Index(line, " ")
+	if space == -1 {
+		return nil, ErrUnknownFormat
+	}
+
+	e := &MlsEntry{
+		Name:  line[space+1:],
+		Facts: make(map[string]string),
+		raw:   line,
+	}
+	for _, fact := range strings.Split(line[:space], ";") {
+		if fact == "" {
+			continue
+		}
+		eq := strings.Index(fact, "=")
+		if eq == -1 {
+			return nil, ErrUnknownFormat
+		}
+		key := strings.ToLower(fact[:eq])
+		value := fact[eq+1:]
+		e.Facts[key] = value
+
+		switch key {
+		case "type":
+			e.Type = strings.ToLower(value)
+		case "size", "sizd":
+			size, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			e.Size = size
+		case "modify":
+			mtime, err := parseMlsxTime(value)
+			if err != nil {
+				return nil, err
+			}
+			e.Modify = mtime
+		case "perm":
+			e.Perm = value
+		case "unique":
+			e.Unique = value
+		}
+	}
+	return e, nil
+}
+
+// parseMlsxFormat parses a MLSD fact line, recognized by its facts terminated with ';'.
+func parseMlsxFormat(input string) (os.FileInfo, error) {
+	space := strings.Index(input, " ")
+	if space < 1 || input[space-1] != ';' || !strings.Contains(input[:space], "=") {
+		return nil, ErrUnknownFormat
+	}
+	e, err := parseMlsxLine(input)
+	if err != nil {
+		return nil, err
+	}
+	return e.FileInfo(), nil
+}
+
+// ParseMlsx parses a fact line of a MLSD listing or of a MLST reply, such as
+// "type=file;size=1024;modify=20200102150405; name". The leading space of the fact
+// line of MLST replies is optional.
+func ParseMlsx(line string) (*MlsEntry, error) {
+	return parseMlsxLine(strings.TrimPrefix(strings.TrimRight(line, "\r\n"), " "))
+}
+
+// parseMlsxTime parses a RFC 3659 time-val "YYYYMMDDHHMMSS[.sss]" in UTC.
+func parseMlsxTime(value string) (time.Time, error) {
+	if len(value) > 14 && value[14] == '.' {
+		return time.Parse("20060102150405.999999999", value)
+	}
+	return time.Parse("20060102150405", value)
+}
+
+// FileInfo returns the entry as an os.FileInfo. The raw facts are available from Sys.
+func (e *MlsEntry) FileInfo() os.FileInfo {
+	var mode os.FileMode
+	var target string
+	switch e.Type {
+	case "dir", "cdir", "pdir":
+		mode |= os.ModeDir
+	case "os.unix=symlink", "os.unix=slink":
+		mode |= os.ModeSymlink
+	default:
+		// some servers append the link target, as in "OS.unix=slink:/target"
+		if strings.HasPrefix(e.Type, "os.unix=slink:") || strings.HasPrefix(e.Type, "os.unix=symlink:") {
+			mode |= os.ModeSymlink
+			target = e.Facts["type"][strings.Index(e.Type, ":")+1:]
+		}
+	}
+	if perm, err := strconv.ParseUint(e.Facts["unix.mode"], 8, 32); err == nil {
+		mode |= os.FileMode(perm) & os.ModePerm
+	}
+
+	owner := e.Facts["unix.ownername"]
+	if owner == "" {
+		owner = e.Facts["unix.owner"]
+	}
+	group := e.Facts["unix.groupname"]
+	if group == "" {
+		group = e.Facts["unix.group"]
+	}
+
+	return &fileInfo{
+		name:   e.Name,
+		size:   e.Size,
+		mode:   mode,
+		mtime:  e.Modify,
+		raw:    e.raw,
+		owner:  owner,
+		group:  group,
+		target: target,
+		facts:  e.Facts,
+	}
 }
@@ -0,0 +1,231 @@
+package ftpclient
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMlsxLine(t *testing.T) {
+	// go test -v -run TestParseMlsxLine
+	cases := []struct {
+		Line   string
+		Name   string
+		Type   string
+		Size   int64
+		Modify time.Time
+	}{
+		{"type=file;size=1024;modify=20200102150405;perm=r; data file.csv", "data file.csv", "file", 1024, time.Date(2020, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{"Type=dir;Modify=20200102150405.123;Unique=801U1; pub", "pub", "dir", 0, time.Date(2020, 1, 2, 15, 4, 5, 123000000, time.UTC)},
+	}
+
+	for _, c := range cases {
+		entry, err := parseMlsxLine(c.Line)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if entry.Name != c.Name || entry.Type != c.Type || entry.Size != c.Size || !entry.Modify.Equal(c.Modify) {
+			t.Errorf("parseMlsxLine(%q) = %+v", c.Line, entry)
+		}
+	}
+}
+
+func TestParseMlsxFormat(t *testing.T) {
+	// go test -v -run TestParseMlsxFormat
+	cases := []struct {
+		Line string
+		Name string
+		Dir  bool
+		Size int64
+	}{
+		{"type=file;size=1024;modify=20200102150405;UNIX.mode=0644; data file.csv", "data file.csv", false, 1024},
+		{"type=dir;modify=20200102150405; pub", "pub", true, 0},
+		{"-rw-r--r--   1 owner group        1024 Jan 02 15:04 a=b; c", "a=b; c", false, 1024},
+	}
+
+	for _, c := range cases {
+		info, err := parse(c.Line)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if info.Name() != c.Name || info.IsDir() != c.Dir || info.Size() != c.Size {
+			t.Errorf("parse(%q) = %q %v %d", c.Line, info.Name(), info.IsDir(), info.Size())
+		}
+	}
+
+	entry, err := ParseMlsx(" type=file;size=3; x\r\n")
+	if err != nil || entry.Name != "x" || entry.Size != 3 {
+		t.Errorf("ParseMlsx = %+v, %v", entry, err)
+	}
+}
+
+func TestParseMVSFormat(t *testing.T) {
+	// go test -v -run TestParseMVSFormat
+	cases := []struct {
+		Line    string
+		Name    string
+		Dir     bool
+		ModTime time.Time
+	}{
+		{"WYPRES 3390   2019/11/20  1   15  FB      80 27920  PO  ISPF.ISPPROF", "ISPF.ISPPROF", true, time.Date(2019, 11, 20, 0, 0, 0, 0, time.UTC)},
+		{"SMS001 3390   **NONE**    2  300  VB    1028 27998  PS  USER.LOG", "USER.LOG", false, time.Time{}},
+		{"Migrated                                                OLD.DATA", "OLD.DATA", false, time.Time{}},
+		{"Pseudo Directory                                        SUBDIR", "SUBDIR", true, time.Time{}},
+		{"MEMBER1   01.03 2019/01/02 2020/03/04 10:20   120   100     0 USER01", "MEMBER1", false, time.Date(2020, 3, 4, 10, 20, 0, 0, time.UTC)},
+		{"MEMBER2", "MEMBER2", false, time.Time{}},
+	}
+
+	c := New(NewConfig().WithListParsers(MVSDatasetListParser, MVSMemberListParser))
+	for _, tc := range cases {
+		info, err := c.parse(tc.Line)
+		if err != nil {
+			t.Errorf("parse(%q): %v", tc.Line, err)
+			continue
+		}
+		if info.Name() != tc.Name || info.IsDir() != tc.Dir || !info.ModTime().Equal(tc.ModTime) {
+			t.Errorf("parse(%q) = %q %v %v", tc.Line, info.Name(), info.IsDir(), info.ModTime())
+		}
+	}
+
+	for _, line := range []string{
+		"Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname",
+		" Name     VV.MM   Created       Changed      Size  Init   Mod   Id",
+	} {
+		if _, err := c.parse(line); err == nil {
+			t.Errorf("parse(%q) accepted a header", line)
+		}
+	}
+
+	// the z/OS formats are not tried by default
+	for _, line := range []string{"MEMBER2", "TOTAL", "Migrated OLD.DATA"} {
+		if _, err := parse(line); err != ErrUnknownFormat {
+			t.Errorf("parse(%q) = %v, want ErrUnknownFormat", line, err)
+		}
+	}
+}
+
+func TestRegisterListParser(t *testing.T) {
+	// go test -v -run TestRegisterListParser
+	defer func(saved []ListParser) { registeredParsers = saved }(registeredParsers)
+
+	line := "file.txt|42"
+	if _, err := parse(line); err != ErrUnknownFormat {
+		t.Fatalf("parse(%q) = %v before registration", line, err)
+	}
+	RegisterListParser(func(line string) (os.FileInfo, error) {
+		fields := strings.Split(line, "|")
+		if len(fields) != 2 {
+			return nil, ErrUnknownFormat
+		}
+		size, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, ErrUnknownFormat
+		}
+		return &fileInfo{name: fields[0], size: size, raw: line}, nil
+	})
+	info, err := parse(line)
+	if err != nil || info.Name() != "file.txt" || info.Size() != 42 {
+		t.Fatalf("parse(%q) = %v, %v", line, info, err)
+	}
+
+	unix := "-rw-r--r--   1 owner group        1024 Jan 02 15:04 a.txt"
+	c := New(NewConfig().WithListParsers(DOSListParser))
+	if _, err := c.parse(unix); err != ErrUnknownFormat {
+		t.Errorf("restricted parse(%q) = %v", unix, err)
+	}
+	if _, err := parse(unix); err != nil {
+		t.Errorf("parse(%q) = %v", unix, err)
+	}
+}
+
+func TestParseUnixDates(t *testing.T) {
+	// go test -v -run TestParseUnixDates
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		Line    string
+		Name    string
+		ModTime time.Time
+	}{
+		{"-rw-r--r-- 1 ftp ftp 10 Mar 4 2019 a.txt", "a.txt", time.Date(2019, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 March 4 10:20 a.txt", "a.txt", time.Date(2024, 3, 4, 10, 20, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 4. Mär 2019 a.txt", "a.txt", time.Date(2019, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 Okt 4 2019 a.txt", "a.txt", time.Date(2019, 10, 4, 0, 0, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 4 févr. 10:20 a b.txt", "a b.txt", time.Date(2024, 2, 4, 10, 20, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 dic 24 1999 a.txt", "a.txt", time.Date(1999, 12, 24, 0, 0, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 3月 4日 2019年 a.txt", "a.txt", time.Date(2019, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 3월 4일 10:20 a.txt", "a.txt", time.Date(2024, 3, 4, 10, 20, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 2019-03-04 10:20 a b.txt", "a b.txt", time.Date(2019, 3, 4, 10, 20, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 2019-03-04 10:20:30.500000000 +0100 a.txt", "a.txt", time.Date(2019, 3, 4, 9, 20, 30, 500000000, time.UTC)},
+	}
+
+	for _, c := range cases {
+		info, err := parseUnixFormatAt(c.Line, now)
+		if err != nil {
+			t.Errorf("parseUnixFormatAt(%q): %v", c.Line, err)
+			continue
+		}
+		if info.Name() != c.Name || !info.ModTime().Equal(c.ModTime) {
+			t.Errorf("parseUnixFormatAt(%q) = %q %v", c.Line, info.Name(), info.ModTime())
+		}
+	}
+}
+
+func TestServerLocation(t *testing.T) {
+	// go test -v -run TestServerLocation
+	c := New(NewConfig().WithServerLocation(time.FixedZone("JST", 9*60*60)))
+	cases := []struct {
+		Line    string
+		ModTime time.Time
+	}{
+		{"-rw-r--r-- 1 ftp ftp 10 Mar 4 2019 a.txt", time.Date(2019, 3, 3, 15, 0, 0, 0, time.UTC)},
+		{"03-04-19  10:20AM                   10 a.txt", time.Date(2019, 3, 4, 1, 20, 0, 0, time.UTC)},
+		{"-rw-r--r-- 1 ftp ftp 10 2019-03-04 10:20:30.000000000 +0100 a.txt", time.Date(2019, 3, 4, 9, 20, 30, 0, time.UTC)},
+		{"type=file;size=10;modify=20190304102030; a.txt", time.Date(2019, 3, 4, 10, 20, 30, 0, time.UTC)},
+	}
+
+	for _, tc := range cases {
+		info, err := c.parse(tc.Line)
+		if err != nil {
+			t.Errorf("parse(%q): %v", tc.Line, err)
+			continue
+		}
+		if !info.ModTime().Equal(tc.ModTime) {
+			t.Errorf("parse(%q) = %v, want %v", tc.Line, info.ModTime(), tc.ModTime)
+		}
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	// go test -v -run TestNewEntry
+	cases := []struct {
+		Line   string
+		Name   string
+		Owner  string
+		Group  string
+		Target string
+	}{
+		{"-rw-r--r-- 1 alice staff 10 Mar 4 2019 a b.txt", "a b.txt", "alice", "staff", ""},
+		{"lrwxrwxrwx 1 root root 7 Mar 4 2019 lib -> usr/lib", "lib", "root", "root", "usr/lib"},
+		{"type=file;size=10;unix.owner=1000;unix.ownername=bob;unix.group=100; c.txt", "c.txt", "bob", "100", ""},
+		{"type=OS.unix=slink:/usr/lib;size=7; lib", "lib", "", "", "/usr/lib"},
+	}
+
+	for _, c := range cases {
+		info, err := parse(c.Line)
+		if err != nil {
+			t.Errorf("parse(%q): %v", c.Line, err)
+			continue
+		}
+		e := NewEntry(info)
+		if e.Name != c.Name || e.Owner != c.Owner || e.Group != c.Group || e.LinkTarget != c.Target || e.Raw != c.Line {
+			t.Errorf("NewEntry(%q) = %+v", c.Line, e)
+		}
+		if (c.Target != "") != (e.Mode&os.ModeSymlink != 0) {
+			t.Errorf("NewEntry(%q).Mode = %v", c.Line, e.Mode)
+		}
+	}
+}
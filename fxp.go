@@ -0,0 +1,184 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// FxpOption configures a server-to-server transfer started with Fxp.
+type FxpOption func(o *fxpOptions)
+
+type fxpOptions struct {
+	timeout       time.Duration
+	passiveTarget bool
+	extended      bool
+	transferType  TransferType
+	cpsv          bool
+	interval      time.Duration
+	monitor       func(FxpProgress)
+	stat          bool
+}
+
+// FxpTimeout sets how long Fxp waits for both servers to report the end of the
+// transfer; the default is ten minutes.
+func FxpTimeout(timeout time.Duration) FxpOption {
+	return func(o *fxpOptions) {
+		o.timeout = timeout
+	}
+}
+
+// FxpPassiveTarget makes the destination server listen with PASV and the source
+// server connect to it with PORT, for sources that refuse passive mode or cannot be
+// reached by the destination. By default the source listens.
+func FxpPassiveTarget() FxpOption {
+	return func(o *fxpOptions) {
+		o.passiveTarget = true
+	}
+}
+
+// FxpExtended pairs the servers with EPSV and EPRT instead of PASV and PORT, as
+// needed for IPv6. The listening server is then reached at its control address.
+func FxpExtended() FxpOption {
+	return func(o *fxpOptions) {
+		o.extended = true
+	}
+}
+
+// FxpType sets the transfer type of both servers; the default is TypeBinary.
+func FxpType(transferType TransferType) FxpOption {
+	return func(o *fxpOptions) {
+		o.transferType = transferType
+	}
+}
+
+// FxpCPSV makes the listening server open protected data connections with CPSV
+// instead of switching the connecting server with SSCN. CPSV is also used without
+// this option when the listening server lists it in its FEAT reply. CPSV has no
+// extended form, so FxpExtended always uses SSCN.
+func FxpCPSV() FxpOption {
+	return func(o *fxpOptions) {
+		o.cpsv = true
+	}
+}
+
+// ErrFxpProtection is returned by Fxp when one server protects its data connections
+// and the other does not.
+var ErrFxpProtection = errors.New("fxp: data protection differs between servers")
+
+// Fxp copies srcPath on source to dstPath on dest directly between the servers (FXP):
+// one server listens with PASV, the other connects to it with PORT, dest is sent
+// STOR and source RETR, and both completion replies are awaited. When both servers
+// protect their data connections, one of them must act as the TLS client: the
+// listening server when it is paired with CPSV, see FxpCPSV, and otherwise the
+// connecting server, switched with SSCN for the duration of the transfer. A failure of the source after
+// dest accepted STOR aborts dest. Failed transfers are retried as a whole according to
+// the RetryPolicy of source. The returned error joins the errors of both sides.
+func Fxp(source *FtpServerConn, srcPath string, dest *FtpServerConn, dstPath string, opts ...FxpOption) error {
+	return FxpContext(context.Background(), source, srcPath, dest, dstPath, opts...)
+}
+
+// FxpContext is like Fxp but honors ctx.
+func FxpContext(ctx context.Context, source *FtpServerConn, srcPath string, dest *FtpServerConn, dstPath string, opts ...FxpOption) error {
+	o := &fxpOptions{timeout: 10 * time.Minute, transferType: TypeBinary}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return source.withContext(ctx, func() error {
+		return dest.withContext(ctx, func() error {
+			// a failed attempt leaves both control connections ready for the next one
+			return source.retry(func(attempt int) error {
+				return fxp(source, srcPath, dest, dstPath, o)
+			})
+		})
+	})
+}
+
+func fxp(source *FtpServerConn, srcPath string, dest *FtpServerConn, dstPath string, o *fxpOptions) (err error) {
+	if err := source.Type(o.transferType); err != nil {
+		return fxpError(source, source, err)
+	}
+	if err := dest.Type(o.transferType); err != nil {
+		return fxpError(source, dest, err)
+	}
+
+	listener, connector := source, dest
+	if o.passiveTarget {
+		listener, connector = dest, source
+	}
+	side := func(c *FtpServerConn, err error) error {
+		return fxpError(source, c, err)
+	}
+
+	cpsv := false
+	switch protected := source.dataProtected(); {
+	case protected != dest.dataProtected():
+		return ErrFxpProtection
+	case protected && !o.extended && (o.cpsv || listener.HasFeature("CPSV")):
+		cpsv = true
+	case protected:
+		if _, _, err := connector.SendCmd(CommandOkay, "SSCN ON"); err != nil {
+			return side(connector, err)
+		}
+		defer func() {
+			if _, _, serr := connector.SendCmd(CommandOkay, "SSCN OFF"); serr != nil {
+				err = errors.Join(err, side(connector, serr))
+			}
+		}()
+	}
+
+	if c, err := fxpPair(listener, connector, o.extended, cpsv); err != nil {
+		return side(c, err)
+	}
+
+	if err := dest.Stor(dstPath); err != nil {
+		return side(dest, err)
+	}
+	if err := source.Retr(srcPath); err != nil {
+		err = side(source, err)
+		if aerr := dest.Abort(); aerr != nil {
+			err = errors.Join(err, side(dest, aerr))
+		}
+		return err
+	}
+
+	return fxpWait(source, dest, o)
+}
+
+// fxpPair makes listener listen, with CPSV when cpsv is set, and connector connect
+// to it. On failure it returns the connection that failed.
+func fxpPair(listener, connector *FtpServerConn, extended, cpsv bool) (*FtpServerConn, error) {
+	if extended {
+		port, err := listener.Epsv()
+		if err != nil {
+			return listener, err
+		}
+		host, _, err := net.SplitHostPort(listener.conn.RemoteAddr().String())
+		if err != nil {
+			return listener, err
+		}
+		return connector, connector.Eprt(host, port)
+	}
+
+	// the listener may advertise its internal address when it is behind NAT
+	pasv := listener.PasvExternal
+	if cpsv {
+		pasv = listener.Cpsv
+	}
+	host, port, err := pasv()
+	if err != nil {
+		return listener, err
+	}
+	return connector, connector.Port(host, port)
+}
+
+// fxpError names the server c of a transfer from source in err.
+func fxpError(source, c *FtpServerConn, err error) error {
+	if c == source {
+		return fmt.Errorf("fxp source: %w", err)
+	}
+	return fmt.Errorf("fxp destination: %w", err)
+}
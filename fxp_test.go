@@ -0,0 +1,60 @@
+package ftpclient
+
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFxpRetry(t *testing.T) {
+	var retrs int32
+	source := testServer(t, func(s *testSession, cmd string) bool {
+		if !strings.HasPrefix(cmd, "RETR ") {
+			return false
+		}
+		// the first attempt fails with a transient reply
+		if atomic.AddInt32(&retrs, 1) == 1 {
+			s.reply("425 can't open data connection")
+			return true
+		}
+		s.reply("150 opening")
+		s.reply("226 sent")
+		return true
+	})
+	var stors int32
+	dest := testServer(t, func(s *testSession, cmd string) bool {
+		switch {
+		case strings.HasPrefix(cmd, "STOR "):
+			s.reply("150 opening")
+			if atomic.AddInt32(&stors, 1) > 1 {
+				s.reply("226 stored")
+			}
+		case strings.HasSuffix(cmd, "ABOR"):
+			s.reply("426 aborted")
+			s.reply("226 abort successful")
+		default:
+			return false
+		}
+		return true
+	})
+
+	retry := &ExponentialBackoff{Attempts: 2, Backoff: time.Millisecond}
+	src := dialTestServer(t, source, NewConfig().WithRetryPolicy(retry))
+	dst := dialTestServer(t, dest, NewConfig())
+
+	if err := Fxp(src, "a.txt", dst, "b.txt", FxpTimeout(5*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&retrs); n != 2 {
+		t.Errorf("RETR sent %d times, want 2", n)
+	}
+
+	// both control connections are in step after the retry
+	if err := src.Noop(); err != nil {
+		t.Error(err)
+	}
+	if err := dst.Noop(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,182 @@
+package ftpclient
+
+import (
+	"errors"
+	"net"
+	"net/textproto"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// FxpSide is the state of one server of a FXP transfer.
+type FxpSide struct {
+	// Status is the text of the last reply to STAT, empty unless FxpStat is given.
+	Status string
+	// Bytes is the byte count found in Status, or -1 when unknown.
+	Bytes int64
+	// Done reports that the server replied to the transfer command.
+	Done bool
+	// Err is the error of the transfer or of the last probe.
+	Err error
+}
+
+// FxpProgress is passed to the callback of FxpMonitor.
+type FxpProgress struct {
+	Elapsed time.Duration
+	Source  FxpSide
+	Dest    FxpSide
+}
+
+// FxpMonitor probes both control connections every interval while the data flows
+// between the servers, with NOOP or with STAT when FxpStat is given, and calls fn
+// with the state of both servers, and once more when the transfer ends. A server
+// whose control connection fails is given up, and the transfer of the other server
+// is aborted instead of waited for.
+func FxpMonitor(interval time.Duration, fn func(FxpProgress)) FxpOption {
+	return func(o *fxpOptions) {
+		o.interval = interval
+		o.monitor = fn
+	}
+}
+
+// FxpStat makes FxpMonitor probe with STAT, which RFC 959 servers answer during a
+// transfer with its status, often including the bytes transferred so far. Servers
+// that refuse STAT during a transfer must not be probed with it, since the refusal
+// would be taken for the reply to the transfer.
+func FxpStat() FxpOption {
+	return func(o *fxpOptions) {
+		o.stat = true
+	}
+}
+
+var regexpStatBytes = regexp.MustCompile(`(\d+) bytes`)
+
+// fxpWait waits for the replies of both servers to their transfer commands. When one
+// side fails, the transfer of the other side is aborted rather than left to hang;
+// a side whose wait timed out is aborted as well, so that both control connections
+// can be used again.
+func fxpWait(source, dest *FtpServerConn, o *fxpOptions) error {
+	type result struct {
+		i   int
+		err error
+	}
+	conns := [2]*FtpServerConn{source, dest}
+	results := make(chan result, 2)
+	for i, c := range conns {
+		c.kaMu.Lock()
+		c.lastStat = ""
+		c.kaMu.Unlock()
+		go func(i int, c *FtpServerConn) {
+			_, _, err := c.GetResponse(2, o.timeout)
+			results <- result{i, err}
+		}(i, c)
+	}
+
+	var tick <-chan time.Time
+	if o.monitor != nil && o.interval > 0 {
+		ticker := time.NewTicker(o.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	start := time.Now()
+	sides := [2]FxpSide{{Bytes: -1}, {Bytes: -1}}
+	var aborted [2]bool
+	report := func() {
+		if o.monitor != nil {
+			o.monitor(FxpProgress{Elapsed: time.Since(start), Source: sides[0], Dest: sides[1]})
+		}
+	}
+	for remaining := 2; remaining > 0; {
+		select {
+		case r := <-results:
+			remaining--
+			sides[r.i].Done, sides[r.i].Err = true, r.err
+			if other := 1 - r.i; r.err != nil && !sides[other].Done && !aborted[other] {
+				aborted[other] = true
+				conns[other].abortWait()
+			}
+		case <-tick:
+			for i, c := range conns {
+				if sides[i].Done {
+					continue
+				}
+				if err := c.probe(o.stat); err != nil {
+					// the wait of a broken connection may otherwise block until the timeout
+					sides[i].Err = err
+					c.conn.SetReadDeadline(aLongTimeAgo)
+				}
+				sides[i].Status, sides[i].Bytes = c.statStatus()
+			}
+			report()
+		}
+	}
+	report()
+
+	var errs []error
+	for i, c := range conns {
+		err := sides[i].Err
+		if err != nil {
+			errs = append(errs, fxpError(source, c, err))
+		}
+
+		var perr *textproto.Error
+		var nerr net.Error
+		switch {
+		case aborted[i] && (err == nil || errors.As(err, &perr)):
+			// the reply to ABOR follows the reply to the transfer command
+			if _, _, aerr := c.getResponse(-1); aerr != nil {
+				errs = append(errs, fxpError(source, c, aerr))
+			}
+		case !aborted[i] && errors.As(err, &nerr) && nerr.Timeout():
+			if aerr := c.Abort(); aerr != nil {
+				errs = append(errs, fxpError(source, c, aerr))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// abortWait sends ABOR while another goroutine waits for the reply to the transfer
+// command. When ABOR cannot be sent, the wait is interrupted.
+func (c *FtpServerConn) abortWait() {
+	if err := c.putAbort(); err != nil {
+		c.conn.SetReadDeadline(aLongTimeAgo)
+	}
+}
+
+// probe sends NOOP, or STAT when stat is set, during a transfer. readResponse skips
+// the reply while it waits for the reply to the transfer command.
+func (c *FtpServerConn) probe(stat bool) error {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	cmd := "NOOP"
+	if stat {
+		cmd = "STAT"
+	}
+	c.logf(cmd)
+	if err := c.writeCmd(cmd); err != nil {
+		return err
+	}
+	c.lastActivity = time.Now()
+	if stat {
+		c.stats++
+	} else {
+		c.noops++
+	}
+	return nil
+}
+
+// statStatus returns the text of the last reply to STAT skipped during a transfer,
+// and the byte count it reports or -1.
+func (c *FtpServerConn) statStatus() (string, int64) {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	if m := regexpStatBytes.FindStringSubmatch(c.lastStat); m != nil {
+		if n, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+			return c.lastStat, n
+		}
+	}
+	return c.lastStat, -1
+}
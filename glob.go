@@ -0,0 +1,136 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Glob returns the remote paths matching pattern, with the syntax of path.Match, e.g.
+// "reports/*.csv" or "/data/2024-*/*.log". Like filepath.Glob, the only error
+// returned is path.ErrBadPattern, besides errors of the connection; directories that
+// cannot be listed have no matches. Directories are listed client side; with
+// WithServerGlob, the last element of the pattern is passed to NLST instead when the
+// directory part has no wildcards.
+func (c *FtpServerConn) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return c.glob(pattern, false)
+}
+
+// GlobContext is like Glob but honors ctx.
+func (c *FtpServerConn) GlobContext(ctx context.Context, pattern string) (matches []string, err error) {
+	err = c.withContext(ctx, func() error {
+		matches, err = c.Glob(pattern)
+		return err
+	})
+	return matches, err
+}
+
+// glob returns the matches of pattern, only directories when dirsOnly is set.
+func (c *FtpServerConn) glob(pattern string, dirsOnly bool) ([]string, error) {
+	if !hasMeta(pattern) {
+		var ok bool
+		var err error
+		if dirsOnly {
+			ok, err = c.IsDir(pattern)
+		} else {
+			ok, err = c.Exists(pattern)
+		}
+		var pathErr *os.PathError
+		if err != nil && !errors.As(err, &pathErr) {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := path.Split(pattern)
+	switch {
+	case dir == "":
+		dir = "."
+	case dir != "/":
+		dir = strings.TrimSuffix(dir, "/")
+	}
+
+	if !hasMeta(dir) {
+		return c.globDir(dir, file, dirsOnly)
+	}
+	dirs, err := c.glob(dir, true)
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, d := range dirs {
+		m, err := c.globDir(d, file, dirsOnly)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, m...)
+	}
+	return matches, nil
+}
+
+// globDir returns the entries of dir matching pattern.
+func (c *FtpServerConn) globDir(dir, pattern string, dirsOnly bool) ([]string, error) {
+	if c.serverGlob && !dirsOnly {
+		if matches, err := c.nlstGlob(dir, pattern); err == nil {
+			return matches, nil
+		} else if !isNoMatch(err) {
+			return nil, err
+		}
+	}
+
+	infos, err := c.readDir(dir)
+	if isNoMatch(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, info := range infos {
+		if dirsOnly && !info.IsDir() {
+			continue
+		}
+		if ok, _ := path.Match(pattern, info.Name()); ok {
+			matches = append(matches, path.Join(dir, info.Name()))
+		}
+	}
+	return matches, nil
+}
+
+// nlstGlob passes pattern to NLST and keeps the names that match it, as servers
+// expand wildcards in their own ways.
+func (c *FtpServerConn) nlstGlob(dir, pattern string) ([]string, error) {
+	lines, err := c.Nlst(path.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	var matches []string
+	for _, line := range lines {
+		name := path.Base(strings.TrimSpace(line))
+		if ok, _ := path.Match(pattern, name); ok {
+			matches = append(matches, path.Join(dir, name))
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// isNoMatch reports whether err is a 450 or 550 reply, with which servers refuse to
+// list missing or unreadable directories and NLST patterns without matches.
+func isNoMatch(err error) bool {
+	return isReply(err, 450) || isReply(err, 550)
+}
+
+// hasMeta reports whether pattern contains any of the special characters of path.Match.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
@@ -0,0 +1,46 @@
+package ftpclient
+
+import (
+	"path"
+	"reflect"
+	"testing"
+)
+
+func TestGlob(t *testing.T) {
+	// go test -v -run TestGlob
+	addr, _ := memServer(t, map[string]string{
+		"top.csv":                "",
+		"reports/a.csv":          "",
+		"reports/b.txt":          "",
+		"data/2023-12/c.log":     "",
+		"data/2024-01/a.log":     "",
+		"data/2024-01/sub/d.log": "",
+		"data/2024-02/b.log":     "",
+	}, nil)
+	c := dialTestServer(t, addr, NewConfig())
+
+	cases := []struct {
+		Pattern string
+		Matches []string
+		Err     error
+	}{
+		{"*.csv", []string{"top.csv"}, nil},
+		{"reports/*.csv", []string{"reports/a.csv"}, nil},
+		{"data/2024-*/*.log", []string{"data/2024-01/a.log", "data/2024-02/b.log"}, nil},
+		{"data/*/sub/*.log", []string{"data/2024-01/sub/d.log"}, nil},
+		{"*/2024-0[2-9]/*", []string{"data/2024-02/b.log"}, nil},
+		// only directories are descended into
+		{"*/*/*", []string{"data/2023-12/c.log", "data/2024-01/a.log", "data/2024-01/sub", "data/2024-02/b.log"}, nil},
+		{"reports/a.csv", []string{"reports/a.csv"}, nil},
+		{"reports/none.csv", nil, nil},
+		{"missing/*.csv", nil, nil},
+		{"reports/[", nil, path.ErrBadPattern},
+	}
+
+	for _, tc := range cases {
+		matches, err := c.Glob(tc.Pattern)
+		if err != tc.Err || !reflect.DeepEqual(matches, tc.Matches) {
+			t.Errorf("Glob(%q) = %q, %v, want %q, %v", tc.Pattern, matches, err, tc.Matches, tc.Err)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/tsujimic/ftpclient-go
+
+go 1.21
+
+require (
+	github.com/spf13/afero v1.11.0
+	golang.org/x/text v0.14.0
+)
@@ -0,0 +1,91 @@
+package ftpclient
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// HashResult is the digest of a file, or of a range of it, computed by the server with
+// the HASH command (draft-ietf-ftpext2-hash). Digest is lower case hex; Start and End
+// delimit the hashed bytes, and are -1 when the server reported no range.
+type HashResult struct {
+	Algorithm string
+	Start     int64
+	End       int64
+	Digest    string
+	Path      string
+}
+
+// parseHashReply parses the text of a 213 reply to HASH, e.g.
+// "SHA-256 0-49 169cd22282da7f147cb491e559e9dd filename".
+func parseHashReply(msg string) (*HashResult, error) {
+	fields := strings.SplitN(strings.TrimSpace(msg), " ", 4)
+	if len(fields) < 3 {
+		return nil, errors.New("Unsuported response format")
+	}
+
+	start, end, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return nil, errors.New("Unsuported response format")
+	}
+	r := &HashResult{Algorithm: fields[0], Digest: strings.ToLower(fields[2])}
+	var err error
+	if r.Start, err = strconv.ParseInt(start, 10, 64); err != nil {
+		return nil, err
+	}
+	if r.End, err = strconv.ParseInt(end, 10, 64); err != nil {
+		return nil, err
+	}
+	if len(fields) == 4 {
+		r.Path = fields[3]
+	}
+	return r, nil
+}
+
+// SetHashAlgorithm selects the algorithm used by HASH with OPTS HASH, e.g. HashSHA256.
+// The algorithms a server supports are listed by Features.HashAlgorithms.
+func (c *FtpServerConn) SetHashAlgorithm(algo string) error {
+	_, _, err := c.SendCmd(CommandOkay, "OPTS HASH %s", algo)
+	return err
+}
+
+// Hash issues a HASH FTP command and returns the digest of path computed by the server.
+func (c *FtpServerConn) Hash(path string) (*HashResult, error) {
+	_, msg, err := c.SendCmd(FileStatus, "HASH %s", path)
+	if err != nil {
+		return nil, err
+	}
+	return parseHashReply(msg)
+}
+
+// HashRange returns the digest of the bytes of path from start up to end, selected
+// with RANG before HASH.
+func (c *FtpServerConn) HashRange(path string, start, end int64) (*HashResult, error) {
+	if _, _, err := c.SendCmd(ActionPending, "RANG %d %d", start, end); err != nil {
+		return nil, err
+	}
+	return c.Hash(path)
+}
+
+// VerifyHash compares the digest of the local file with the digest of remote computed
+// by the server with algo, after selecting it with OPTS HASH.
+func (c *FtpServerConn) VerifyHash(local, remote, algo string) error {
+	digest, err := localDigest(local, algo)
+	if err != nil {
+		return err
+	}
+
+	if err = c.SetHashAlgorithm(algo); err != nil {
+		return err
+	}
+	result, err := c.Hash(remote)
+	if err != nil {
+		return err
+	}
+	if result.Digest != digest {
+		return fmt.Errorf("hash verification failed for %q: remote %s %s, local %s", remote, result.Algorithm, result.Digest, digest)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package ftpclient
+
+import "testing"
+
+func TestParseHashReply(t *testing.T) {
+	// go test -v -run TestParseHashReply
+	r, err := parseHashReply("SHA-256 0-49 169CD22282DA7F147CB491E559E9DD my file.txt")
+	if err != nil || r.Algorithm != "SHA-256" || r.Start != 0 || r.End != 49 || r.Digest != "169cd22282da7f147cb491e559e9dd" || r.Path != "my file.txt" {
+		t.Errorf("parseHashReply() = %+v, %v", r, err)
+	}
+	if _, err = parseHashReply("SHA-256 49 abc"); err == nil {
+		t.Errorf("parseHashReply() accepted a malformed range")
+	}
+}
@@ -0,0 +1,88 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+)
+
+// trackCwd records the working directory after a successful CWD to dir.
+// A relative dir is only tracked when the previous directory is known.
+func (c *FtpServerConn) trackCwd(dir string) {
+	switch {
+	case path.IsAbs(dir):
+		c.cwd = path.Clean(dir)
+	case c.cwd != "":
+		c.cwd = path.Join(c.cwd, dir)
+	}
+}
+
+// WorkingDir returns the tracked working directory, asking the server with PWD when
+// it is not known yet.
+func (c *FtpServerConn) WorkingDir() (string, error) {
+	if c.cwd != "" {
+		return c.cwd, nil
+	}
+	return c.Pwd()
+}
+
+// here returns name resolved against the tracked working directory.
+func (c *FtpServerConn) here(name string) (string, error) {
+	if path.IsAbs(name) {
+		return name, nil
+	}
+	cwd, err := c.WorkingDir()
+	if err != nil {
+		return "", err
+	}
+	return path.Join(cwd, name), nil
+}
+
+// ListHere lists the tracked working directory. Paths are resolved on the client,
+// so the listing stays correct after Reconnect even if the server starts the new
+// session in another directory.
+func (c *FtpServerConn) ListHere() ([]os.FileInfo, error) {
+	dir, err := c.WorkingDir()
+	if err != nil {
+		return nil, err
+	}
+	return c.readDir(dir)
+}
+
+// GetHere downloads name, relative to the tracked working directory, into local.
+func (c *FtpServerConn) GetHere(name, local string) error {
+	remote, err := c.here(name)
+	if err != nil {
+		return err
+	}
+	return c.RetrFile(remote, local)
+}
+
+// PutHere uploads local as name, relative to the tracked working directory.
+func (c *FtpServerConn) PutHere(local, name string) error {
+	remote, err := c.here(name)
+	if err != nil {
+		return err
+	}
+	return c.StorFile(local, remote)
+}
+
+// Reconnect replaces a broken control connection with a new one to the same server,
+// logs in again and restores the transfer type and the tracked working directory.
+func (c *FtpServerConn) Reconnect(ctx context.Context) error {
+	if c.addr == "" {
+		return errors.New("reconnect: connection is not dialed")
+	}
+
+	if c.textprotoConn != nil {
+		c.textprotoConn.Close()
+	}
+	if err := c.dial(ctx, c.addr, 0); err != nil {
+		return err
+	}
+
+	return c.withContext(ctx, func() error {
+		return c.restore(c.user, c.password, c.cwd, c.transferType)
+	})
+}
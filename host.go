@@ -0,0 +1,32 @@
+package ftpclient
+
+import (
+	"net/textproto"
+)
+
+// Host issues a HOST FTP command (RFC 7151) selecting the virtual host name of the
+// server. It must be sent before logging in.
+func (c *FtpServerConn) Host(name string) error {
+	code, msg, err := c.SendCmd(-1, "HOST %s", name)
+	if err != nil {
+		return err
+	}
+	if code < 200 || code > 299 {
+		return &textproto.Error{Code: code, Msg: msg}
+	}
+	c.hostSent = true
+	return nil
+}
+
+// sendHost sends the configured virtual host name once per control connection.
+// Servers that do not implement HOST serve a single host, so the login proceeds.
+func (c *FtpServerConn) sendHost() error {
+	if c.virtualHost == "" || c.hostSent {
+		return nil
+	}
+	if err := c.Host(c.virtualHost); err != nil && !isNotImplemented(err) {
+		return err
+	}
+	c.hostSent = true
+	return nil
+}
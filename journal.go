@@ -0,0 +1,309 @@
+package ftpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Journal directions.
+const (
+	JournalGet = "get"
+	JournalPut = "put"
+)
+
+// Journal entry states.
+const (
+	JournalPending    = "pending"
+	JournalInProgress = "in-progress"
+)
+
+// journalCheckpointEvery is the number of bytes between persisted offsets.
+const journalCheckpointEvery = 1024 * 1024
+
+// JournalEntry is a queued transfer persisted by a Journal.
+// Offset is the byte offset reached so far, used with REST when resuming. As bytes
+// sent on a data connection may never reach the server, uploads resume from the
+// size of the remote file instead.
+type JournalEntry struct {
+	ID        string `json:"id"`
+	Direction string `json:"direction"`
+	Local     string `json:"local"`
+	Remote    string `json:"remote"`
+	Offset    int64  `json:"offset"`
+	State     string `json:"state"`
+}
+
+// Journal persists a queue of pending and in-progress transfers to a JSON file, so
+// that a crashed batch transfer restarts where it left off. Completed transfers
+// are removed from the journal. A Journal is safe for concurrent use.
+type Journal struct {
+	mu      sync.Mutex
+	path    string
+	entries []*JournalEntry
+}
+
+// OpenJournal loads the journal stored at path, or returns an empty journal when the
+// file does not exist yet.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, &j.entries); err != nil {
+		return nil, fmt.Errorf("could not load journal %q: %v", path, err)
+	}
+	return j, nil
+}
+
+// Add queues a transfer. The ID defaults to "direction:remote". Adding an ID that is
+// already queued keeps the existing entry and its offset.
+func (j *Journal) Add(e JournalEntry) error {
+	if e.ID == "" {
+		e.ID = e.Direction + ":" + e.Remote
+	}
+	if e.State == "" {
+		e.State = JournalPending
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.find(e.ID) != nil {
+		return nil
+	}
+	j.entries = append(j.entries, &e)
+	return j.save()
+}
+
+// Start marks a transfer as in progress.
+func (j *Journal) Start(id string) error {
+	return j.update(id, func(e *JournalEntry) {
+		e.State = JournalInProgress
+	})
+}
+
+// Checkpoint records the byte offset reached by a transfer.
+func (j *Journal) Checkpoint(id string, offset int64) error {
+	return j.update(id, func(e *JournalEntry) {
+		e.Offset = offset
+	})
+}
+
+// Done removes a completed transfer from the journal.
+func (j *Journal) Done(id string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i, e := range j.entries {
+		if e.ID == id {
+			j.entries = append(j.entries[:i], j.entries[i+1:]...)
+			return j.save()
+		}
+	}
+	return nil
+}
+
+// Entries returns a copy of the queued transfers in order.
+func (j *Journal) Entries() []JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entries := make([]JournalEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		entries = append(entries, *e)
+	}
+	return entries
+}
+
+// Run performs every queued transfer on c, resuming each one from its recorded offset.
+// Failed transfers are retried according to the RetryPolicy of c. Run stops at the
+// first failure, leaving the failed and remaining entries queued.
+func (j *Journal) Run(c *FtpServerConn) error {
+	for _, e := range j.Entries() {
+		resumed := e.State == JournalInProgress
+		if err := j.Start(e.ID); err != nil {
+			return err
+		}
+
+		// retries resume from the offset checkpointed by the failed attempt
+		err := c.retry(func(attempt int) error {
+			if current := j.entry(e.ID); current != nil {
+				e = *current
+			}
+			switch e.Direction {
+			case JournalGet:
+				return j.get(c, e)
+			case JournalPut:
+				return j.put(c, e, resumed || attempt > 0)
+			}
+			return fmt.Errorf("unknown journal direction: %q", e.Direction)
+		})
+		if err != nil {
+			return err
+		}
+
+		if err = j.Done(e.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get resumes a download at e.Offset, or at the end of the local file when it is
+// shorter.
+func (j *Journal) get(c *FtpServerConn, e JournalEntry) (err error) {
+	file, err := os.OpenFile(e.Local, os.O_WRONLY|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	// the local file may be shorter than the journal offset when the last writes
+	// were lost; resume at its end rather than padding it with zeros
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < e.Offset {
+		e.Offset = info.Size()
+		if err = j.Checkpoint(e.ID, e.Offset); err != nil {
+			return err
+		}
+	}
+	if err = file.Truncate(e.Offset); err != nil {
+		return err
+	}
+	if _, err = file.Seek(e.Offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err = c.RetrTo(e.Remote, j.checkpointWriter(file, e), uint64(e.Offset))
+	return err
+}
+
+// put uploads e.Local. When resume is set, the upload continues at the size of the
+// remote file, the only offset the server is known to have stored.
+func (j *Journal) put(c *FtpServerConn, e JournalEntry, resume bool) error {
+	file, err := os.Open(e.Local)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var offset int64
+	if resume {
+		if offset, err = j.remoteOffset(c, e, file); err != nil {
+			return err
+		}
+	}
+	if err = j.Checkpoint(e.ID, offset); err != nil {
+		return err
+	}
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = c.StorFrom(e.Remote, file, uint64(offset))
+	return err
+}
+
+// remoteOffset returns the size of the partial upload of e, or 0 when the upload
+// has to start over.
+func (j *Journal) remoteOffset(c *FtpServerConn, e JournalEntry, file *os.File) (int64, error) {
+	size, err := c.Size(e.Remote)
+	if isReply(err, 550) || isNotImplemented(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if int64(size) > info.Size() {
+		// not a prefix of the local file
+		return 0, nil
+	}
+	return int64(size), nil
+}
+
+// checkpointWriter returns a writer recording the offset of e as data passes through.
+func (j *Journal) checkpointWriter(w io.Writer, e JournalEntry) *checkpointWriter {
+	return &checkpointWriter{
+		w:      w,
+		remote: e.Remote,
+		offset: e.Offset,
+		next:   e.Offset + journalCheckpointEvery,
+		every:  journalCheckpointEvery,
+		fn: func(remote string, offset int64) {
+			j.Checkpoint(e.ID, offset)
+		},
+	}
+}
+
+func (j *Journal) update(id string, fn func(e *JournalEntry)) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := j.find(id)
+	if e == nil {
+		return fmt.Errorf("no journal entry: %q", id)
+	}
+	fn(e)
+	return j.save()
+}
+
+// entry returns a copy of the entry with the given ID, or nil.
+func (j *Journal) entry(id string) *JournalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if e := j.find(id); e != nil {
+		copied := *e
+		return &copied
+	}
+	return nil
+}
+
+func (j *Journal) find(id string) *JournalEntry {
+	for _, e := range j.entries {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+// save writes the journal to a temporary file and renames it into place, so that a
+// crash never leaves a truncated journal behind.
+func (j *Journal) save() error {
+	data, err := json.MarshalIndent(j.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err = temp.Write(data); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return err
+	}
+	return os.Rename(temp.Name(), j.path)
+}
@@ -0,0 +1,181 @@
+package ftpclient
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestJournalResumePut(t *testing.T) {
+	cases := []struct {
+		Name    string
+		State   string
+		Offset  int64
+		Size    string
+		Rest    string
+		Stored  string
+		NoQuery bool
+	}{
+		{"pending", JournalPending, 0, "213 4", "", "hello world", true},
+		{"remote size", JournalInProgress, 0, "213 4", "REST 4", "o world", false},
+		{"stale offset", JournalInProgress, 8, "213 4", "REST 4", "o world", false},
+		{"no remote file", JournalInProgress, 8, "550 not found", "", "hello world", false},
+		{"no size command", JournalInProgress, 8, "502 not implemented", "", "hello world", false},
+		{"larger remote file", JournalInProgress, 8, "213 64", "", "hello world", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var mu sync.Mutex
+			var cmds []string
+			var stored string
+			addr := testServer(t, func(s *testSession, cmd string) bool {
+				mu.Lock()
+				cmds = append(cmds, cmd)
+				mu.Unlock()
+				switch {
+				case strings.HasPrefix(cmd, "SIZE "):
+					s.reply(tc.Size)
+				case strings.HasPrefix(cmd, "REST "):
+					s.reply("350 restarting")
+				case strings.HasPrefix(cmd, "STOR "):
+					s.reply("150 opening")
+					conn, err := s.accept()
+					if err != nil {
+						return true
+					}
+					data, _ := io.ReadAll(conn)
+					conn.Close()
+					mu.Lock()
+					stored = string(data)
+					mu.Unlock()
+					s.reply("226 stored")
+				default:
+					return false
+				}
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig())
+
+			dir := t.TempDir()
+			local := filepath.Join(dir, "file.txt")
+			if err := os.WriteFile(local, []byte("hello world"), 0666); err != nil {
+				t.Fatal(err)
+			}
+			j, err := OpenJournal(filepath.Join(dir, "journal.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			j.Add(JournalEntry{Direction: JournalPut, Local: local, Remote: "file.txt", Offset: tc.Offset, State: tc.State})
+
+			if err = j.Run(c); err != nil {
+				t.Fatal(err)
+			}
+			if entries := j.Entries(); len(entries) != 0 {
+				t.Errorf("entries = %v, want none", entries)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			var rest string
+			var queried bool
+			for _, cmd := range cmds {
+				if strings.HasPrefix(cmd, "REST ") {
+					rest = cmd
+				}
+				if strings.HasPrefix(cmd, "SIZE ") {
+					queried = true
+				}
+			}
+			if rest != tc.Rest {
+				t.Errorf("rest = %q, want %q", rest, tc.Rest)
+			}
+			if queried == tc.NoQuery {
+				t.Errorf("queried size = %v, want %v", queried, !tc.NoQuery)
+			}
+			if stored != tc.Stored {
+				t.Errorf("stored = %q, want %q", stored, tc.Stored)
+			}
+		})
+	}
+}
+
+func TestJournalResumeGet(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Local  string
+		Offset int64
+		Rest   string
+	}{
+		{"offset", "hello", 5, "REST 5"},
+		// the local file lost writes recorded by the journal
+		{"short local file", "hel", 8, "REST 3"},
+		{"missing local file", "", 8, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var mu sync.Mutex
+			var offset int
+			var rest string
+			addr := testServer(t, func(s *testSession, cmd string) bool {
+				switch {
+				case strings.HasPrefix(cmd, "REST "):
+					mu.Lock()
+					rest = cmd
+					offset, _ = strconv.Atoi(strings.TrimPrefix(cmd, "REST "))
+					mu.Unlock()
+					s.reply("350 restarting")
+				case strings.HasPrefix(cmd, "RETR "):
+					s.reply("150 opening")
+					conn, err := s.accept()
+					if err != nil {
+						return true
+					}
+					mu.Lock()
+					io.WriteString(conn, "hello world"[offset:])
+					mu.Unlock()
+					conn.Close()
+					s.reply("226 sent")
+				default:
+					return false
+				}
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig())
+
+			dir := t.TempDir()
+			local := filepath.Join(dir, "file.txt")
+			if tc.Local != "" {
+				if err := os.WriteFile(local, []byte(tc.Local), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			j, err := OpenJournal(filepath.Join(dir, "journal.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			j.Add(JournalEntry{Direction: JournalGet, Local: local, Remote: "file.txt", Offset: tc.Offset, State: JournalInProgress})
+
+			if err = j.Run(c); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(local)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != "hello world" {
+				t.Errorf("local file = %q, want %q", data, "hello world")
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if rest != tc.Rest {
+				t.Errorf("rest = %q, want %q", rest, tc.Rest)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package ftpclient
+
+import (
+	"time"
+)
+
+// startKeepAlive starts sending NOOP on the control connection once it has been
+// idle for the configured period. It runs until Quit.
+func (c *FtpServerConn) startKeepAlive() {
+	if c.keepAlive <= 0 || c.keepAliveStop != nil {
+		return
+	}
+	c.kaMu.Lock()
+	c.lastActivity = time.Now()
+	c.kaMu.Unlock()
+
+	stop := make(chan struct{})
+	c.keepAliveStop = stop
+	go func() {
+		ticker := time.NewTicker(c.keepAlive / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := c.keepAliveTick(); err != nil {
+					c.errorf("keepalive: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// stopKeepAlive stops the keepalive goroutine.
+func (c *FtpServerConn) stopKeepAlive() {
+	if c.keepAliveStop != nil {
+		close(c.keepAliveStop)
+		c.keepAliveStop = nil
+	}
+}
+
+// keepAliveTick sends NOOP when the control connection has been idle long enough.
+// Between commands the reply is read right away, holding kaMu so that no command
+// can interleave. During a transfer, when enabled, the reply is left to readResponse,
+// which discards it while waiting for the transfer completion reply.
+func (c *FtpServerConn) keepAliveTick() error {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	if time.Since(c.lastActivity) < c.keepAlive {
+		return nil
+	}
+
+	switch {
+	case c.pending == 0:
+		c.logf("NOOP")
+		if err := c.writeCmd("NOOP"); err != nil {
+			return err
+		}
+		c.lastActivity = time.Now()
+		c.setReadDeadline(c.conn, c.timeout(c.commandTimeout))
+		_, _, err := c.readReply(CommandOkay)
+		return err
+	case c.pending == 1 && c.preliminary && c.keepAliveTransfers:
+		c.logf("NOOP")
+		if err := c.writeCmd("NOOP"); err != nil {
+			return err
+		}
+		c.lastActivity = time.Now()
+		c.noops++
+	}
+	return nil
+}
+
+// IdleSince returns the time of the last command or reply on the control connection.
+func (c *FtpServerConn) IdleSince() time.Time {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	return c.lastActivity
+}
+
+// NoopIfIdle sends NOOP when the control connection has been idle for at least idle,
+// so that a workflow pausing between steps is not disconnected by the server idle
+// timer. WithKeepAlive does the same in the background.
+func (c *FtpServerConn) NoopIfIdle(idle time.Duration) error {
+	if time.Since(c.IdleSince()) < idle {
+		return nil
+	}
+	return c.Noop()
+}
+
+// trackReply updates the reply bookkeeping of the keepalive and reports whether the
+// reply answers a NOOP or STAT sent during a transfer and must be skipped.
+func (c *FtpServerConn) trackReply(code int, msg string) bool {
+	c.kaMu.Lock()
+	defer c.kaMu.Unlock()
+	c.lastActivity = time.Now()
+	if code == CommandOkay && c.noops > 0 {
+		c.noops--
+		return true
+	}
+	if code >= SystemStatus && code <= FileStatus && c.stats > 0 {
+		c.stats--
+		c.lastStat = msg
+		return true
+	}
+	if code >= 200 && c.pending > 0 {
+		c.pending--
+	}
+	c.preliminary = code > 0 && code < 200
+	return false
+}
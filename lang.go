@@ -0,0 +1,13 @@
+package ftpclient
+
+// Lang issues a LANG FTP command (RFC 2640) asking the server to send its replies in
+// the language tag, e.g. "FR" or "EN-US". An empty tag restores the default language.
+// The languages a server supports are listed by Features.Languages.
+func (c *FtpServerConn) Lang(tag string) error {
+	if tag == "" {
+		_, _, err := c.SendCmd(CommandOkay, "LANG")
+		return err
+	}
+	_, _, err := c.SendCmd(CommandOkay, "LANG %s", tag)
+	return err
+}
@@ -0,0 +1,61 @@
+package ftpclient
+
+import (
+	"os"
+	"sync"
+)
+
+// ListParser parses one line of a LIST reply. It returns ErrUnknownFormat when the
+// line is not in its format, so that the next parser is tried.
+type ListParser func(line string) (os.FileInfo, error)
+
+// Built-in list parsers. Mlsx, Unix and DOS are tried in this order by default; the
+// z/OS parsers are only used when enabled with WithListParsers, for example:
+//
+//	NewConfig().WithListParsers(MVSDatasetListParser, MVSMemberListParser)
+var (
+	MlsxListParser       ListParser = parseMlsxFormat
+	MVSDatasetListParser ListParser = parseMVSDatasetFormat
+	MVSMemberListParser  ListParser = parseMVSMemberFormat
+	UnixListParser       ListParser = parseUnixFormat
+	DOSListParser        ListParser = parseDosFormat
+)
+
+var (
+	registeredMu      sync.RWMutex
+	registeredParsers []ListParser
+)
+
+// RegisterListParser adds parser to the parsers tried on every LIST line, ahead of the
+// built-in ones, so that applications can support unusual servers. Parsers registered
+// later are tried first. Sessions configured with WithListParsers only use theirs.
+func RegisterListParser(parser func(line string) (os.FileInfo, error)) {
+	registeredMu.Lock()
+	defer registeredMu.Unlock()
+	registeredParsers = append([]ListParser{parser}, registeredParsers...)
+}
+
+// listParsers returns the registered parsers followed by the built-in ones.
+func listParsers() []ListParser {
+	registeredMu.RLock()
+	defer registeredMu.RUnlock()
+	if len(registeredParsers) == 0 {
+		return formatParsers
+	}
+	parsers := make([]ListParser, 0, len(registeredParsers)+len(formatParsers))
+	parsers = append(parsers, registeredParsers...)
+	return append(parsers, formatParsers...)
+}
+
+// parse parses a LIST line with the parsers of the session, in the timezone of the server.
+func (c *FtpServerConn) parse(line string) (info os.FileInfo, err error) {
+	if len(c.listParsers) > 0 {
+		info, err = parseWith(c.listParsers, line)
+	} else {
+		info, err = parse(line)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.localize(info), nil
+}
@@ -3,6 +3,7 @@ package ftpclient
 import (
 	"log"
 	"os"
+	"strconv"
 )
 
 // Logger ...
@@ -11,6 +12,39 @@ type Logger interface {
 	Logf(format string, v ...interface{})
 }
 
+// Level is the severity of a log message.
+type Level int
+
+// Log levels. Commands and replies are logged at LevelDebug, transfers at LevelInfo.
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// LeveledLogger is a Logger that also receives the level of each message.
+// A plain Logger receives every message at or above the configured level through Logf.
+type LeveledLogger interface {
+	Logger
+	LogLevel(level Level, format string, v ...interface{})
+}
+
 // NewDefaultLogger ...
 func NewDefaultLogger() Logger {
 	return &defaultLogger{
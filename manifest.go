@@ -0,0 +1,110 @@
+package ftpclient
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// Checksum algorithm names, as used by the HASH command.
+const (
+	HashCRC32  = "CRC32"
+	HashMD5    = "MD5"
+	HashSHA1   = "SHA-1"
+	HashSHA256 = "SHA-256"
+	HashSHA512 = "SHA-512"
+)
+
+// ManifestEntry describes one file of a checksum manifest.
+// Path is relative to the manifest root and Digest is lower case hex.
+type ManifestEntry struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	Digest  string
+}
+
+// newHash returns a hash.Hash for one of the Hash* algorithm names.
+func newHash(algo string) (hash.Hash, error) {
+	switch strings.ToUpper(algo) {
+	case HashCRC32:
+		return crc32.NewIEEE(), nil
+	case HashMD5:
+		return md5.New(), nil
+	case HashSHA1, "SHA1":
+		return sha1.New(), nil
+	case HashSHA256, "SHA256":
+		return sha256.New(), nil
+	case HashSHA512, "SHA512":
+		return sha512.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm: %q", algo)
+}
+
+// Manifest walks the remote tree rooted at dir and returns the size, modification
+// time and digest of every regular file, computed with algo by streaming each file
+// through the client. The transfer type should be set to binary beforehand.
+func (c *FtpServerConn) Manifest(dir, algo string) ([]ManifestEntry, error) {
+	if _, err := newHash(algo); err != nil {
+		return nil, err
+	}
+
+	var entries []ManifestEntry
+	err := c.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		digest, err := c.fileDigest(name, algo)
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, path.Clean(dir)), "/")
+		entries = append(entries, ManifestEntry{
+			Path:    rel,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			Digest:  digest,
+		})
+		return nil
+	})
+	return entries, err
+}
+
+// fileDigest downloads remote and returns its hex digest.
+func (c *FtpServerConn) fileDigest(remote, algo string) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+	if _, err = c.RetrTo(remote, h, 0); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteManifest writes entries to w, one "digest  size  modtime  path" line per file,
+// with the modification time in RFC 3339 format.
+func WriteManifest(w io.Writer, entries []ManifestEntry) error {
+	for _, e := range entries {
+		_, err := fmt.Fprintf(w, "%s  %d  %s  %s\n", e.Digest, e.Size, e.ModTime.UTC().Format(time.RFC3339), e.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
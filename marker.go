@@ -0,0 +1,65 @@
+package ftpclient
+
+import (
+	"fmt"
+	"path"
+)
+
+// MarkerNamer returns the name of the marker file announcing that remote has been
+// delivered, or an empty string to skip the marker.
+type MarkerNamer func(remote string) string
+
+// SuffixMarker names the marker after the uploaded file, e.g. "data.csv.done".
+func SuffixMarker(suffix string) MarkerNamer {
+	return func(remote string) string {
+		return remote + suffix
+	}
+}
+
+// FixedMarker writes a marker with a fixed name, e.g. "trigger", in the directory of
+// the uploaded file.
+func FixedMarker(name string) MarkerNamer {
+	return func(remote string) string {
+		return path.Join(path.Dir(remote), name)
+	}
+}
+
+// deliver verifies an upload and writes its marker file when a MarkerNamer is configured.
+func (c *FtpServerConn) deliver(local, remote string) error {
+	if c.markerNamer == nil {
+		return nil
+	}
+	if err := c.verifyUpload(local, remote); err != nil {
+		return err
+	}
+	return c.writeMarker(remote)
+}
+
+// verifyUpload compares the remote SIZE with the local size. Servers without SIZE
+// cannot be verified and are trusted.
+func (c *FtpServerConn) verifyUpload(local, remote string) error {
+	n, err := c.Size(remote)
+	if isNotImplemented(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if size := localSize(local); int64(n) != size {
+		return fmt.Errorf("upload verification failed for %q: remote size %d, local size %d", remote, n, size)
+	}
+	return nil
+}
+
+// writeMarker stores an empty marker file for remote.
+func (c *FtpServerConn) writeMarker(remote string) error {
+	name := c.markerNamer(remote)
+	if name == "" {
+		return nil
+	}
+	w, err := c.StorRequest(name)
+	if err != nil {
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,59 @@
+package ftpclient
+
+import (
+	"strings"
+	"time"
+)
+
+// Metrics receives instrumentation events from a FtpServerConn. It is a neutral
+// hook: an adapter may for instance count replies by command and code, observe
+// transfer durations and sizes in histograms and expose open data connections as a
+// gauge. Methods are called synchronously and must be safe for concurrent use when
+// the Config is shared by several connections.
+type Metrics interface {
+	// CommandReply is called for every reply read on the control connection. command
+	// is the verb of the command being answered, such as "RETR", or empty for the greeting.
+	CommandReply(command string, code int)
+	// TransferDone is called when a data connection is closed. command is the verb
+	// that opened it, bytes the data moved over it and err the outcome of the transfer.
+	TransferDone(command string, bytes int64, duration time.Duration, err error)
+	// DataConnOpened and DataConnClosed are called as data connections open and close.
+	DataConnOpened()
+	DataConnClosed()
+}
+
+// commandVerb returns the upper case verb of a command line or format.
+func commandVerb(format string) string {
+	verb := format
+	if i := strings.IndexByte(verb, ' '); i >= 0 {
+		verb = verb[:i]
+	}
+	return strings.ToUpper(verb)
+}
+
+// observeReply reports a reply to the configured Metrics.
+func (c *FtpServerConn) observeReply(code int) {
+	if c.metrics != nil && code != 0 {
+		c.metrics.CommandReply(c.command, code)
+	}
+}
+
+// observeOpen reports a new data connection to the configured Metrics.
+func (d *FtpDataConn) observeOpen() {
+	if d.c.metrics == nil {
+		return
+	}
+	d.command = d.c.command
+	d.opened = time.Now()
+	d.c.metrics.DataConnOpened()
+}
+
+// observeClose reports the end of the transfer to the configured Metrics, once.
+func (d *FtpDataConn) observeClose(err error) {
+	if d.c.metrics == nil || d.opened.IsZero() {
+		return
+	}
+	d.c.metrics.DataConnClosed()
+	d.c.metrics.TransferDone(d.command, d.n, time.Since(d.opened), err)
+	d.opened = time.Time{}
+}
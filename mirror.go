@@ -0,0 +1,247 @@
+package ftpclient
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DirOption configures directory transfers such as DownloadDir.
+type DirOption func(o *dirOptions)
+
+type dirOptions struct {
+	concurrency int
+}
+
+// DirConcurrency sets the number of files transferred in parallel. Additional control
+// connections are opened with Clone; the default is one.
+func DirConcurrency(n int) DirOption {
+	return func(o *dirOptions) {
+		o.concurrency = n
+	}
+}
+
+func newDirOptions(opts []DirOption) *dirOptions {
+	o := &dirOptions{concurrency: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency < 1 {
+		o.concurrency = 1
+	}
+	return o
+}
+
+// fileTask is a single file of a directory or batch transfer.
+type fileTask struct {
+	remote string
+	local  string
+	upload bool
+}
+
+// DownloadDir walks the remote tree rooted at remote, recreates its directory
+// structure below local and downloads every regular file. The result reports the
+// outcome of every file; the error joins the walk error and the failed files.
+func (c *FtpServerConn) DownloadDir(remote, local string, opts ...DirOption) (*BatchResult, error) {
+	return c.DownloadDirContext(context.Background(), remote, local, opts...)
+}
+
+// DownloadDirContext is like DownloadDir but honors ctx.
+func (c *FtpServerConn) DownloadDirContext(ctx context.Context, remote, local string, opts ...DirOption) (*BatchResult, error) {
+	o := newDirOptions(opts)
+
+	var tasks []fileTask
+	root := path.Clean(remote)
+	err := c.WalkContext(ctx, root, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, root), "/")
+		target := filepath.Join(local, filepath.FromSlash(rel))
+		if info.IsDir() {
+			return os.MkdirAll(target, 0777)
+		}
+		if info.Mode().IsRegular() {
+			tasks = append(tasks, fileTask{remote: name, local: target})
+		}
+		return nil
+	})
+	if err != nil {
+		return &BatchResult{}, err
+	}
+
+	result := c.runFileTasks(ctx, tasks, o.concurrency, downloadTask)
+	return result, result.Err()
+}
+
+// UploadDir walks the local tree rooted at local, recreates its directory structure
+// below remote and uploads every regular file. Remote names pass through the
+// configured NameMapper. The result reports the outcome of every file; the error
+// joins the walk error and the failed files.
+func (c *FtpServerConn) UploadDir(local, remote string, opts ...DirOption) (*BatchResult, error) {
+	return c.UploadDirContext(context.Background(), local, remote, opts...)
+}
+
+// UploadDirContext is like UploadDir but honors ctx.
+func (c *FtpServerConn) UploadDirContext(ctx context.Context, local, remote string, opts ...DirOption) (*BatchResult, error) {
+	o := newDirOptions(opts)
+
+	var tasks []fileTask
+	root := path.Clean(remote)
+	err := c.withContext(ctx, func() error {
+		return filepath.WalkDir(local, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			rel, err := filepath.Rel(local, name)
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				target := root
+				if rel != "." {
+					target = path.Join(root, c.mapPath(filepath.ToSlash(rel)))
+				}
+				return c.mkdir(target)
+			}
+			if d.Type().IsRegular() {
+				// uploadTask maps the file name
+				dir, file := path.Split(filepath.ToSlash(rel))
+				target := path.Join(root, c.mapPath(strings.TrimSuffix(dir, "/")), file)
+				tasks = append(tasks, fileTask{remote: target, local: name})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return &BatchResult{}, err
+	}
+
+	result := c.runFileTasks(ctx, tasks, o.concurrency, uploadTask)
+	return result, result.Err()
+}
+
+// downloadTask downloads t over conn, retrying according to the RetryPolicy.
+func downloadTask(ctx context.Context, conn *FtpServerConn, t fileTask, item *BatchItem) error {
+	err := conn.withContext(ctx, func() error {
+		return conn.retry(func(attempt int) error {
+			item.Retries = attempt
+			return conn.retrFileAttempt(t.remote, t.local, attempt)
+		})
+	})
+	item.Bytes = localSize(t.local)
+	return err
+}
+
+// uploadTask uploads t over conn, retrying according to the RetryPolicy. The file
+// name passes through the configured NameMapper.
+func uploadTask(ctx context.Context, conn *FtpServerConn, t fileTask, item *BatchItem) error {
+	remote := conn.mapName(t.remote)
+	item.Remote = remote
+	err := conn.withContext(ctx, func() error {
+		err := conn.retry(func(attempt int) error {
+			item.Retries = attempt
+			return conn.storFileAttempt(t.local, remote, attempt)
+		})
+		if err != nil {
+			return err
+		}
+		return conn.deliver(t.local, remote)
+	})
+	if err == nil {
+		item.Bytes = localSize(t.local)
+	}
+	return err
+}
+
+// mkdir creates dir unless it already exists.
+func (c *FtpServerConn) mkdir(dir string) error {
+	_, err := c.Mkd(dir)
+	if err == nil {
+		return nil
+	}
+	root, name := "", path.Clean(dir)
+	if path.IsAbs(name) {
+		root, name = "/", strings.TrimPrefix(name, "/")
+	}
+	if name == "" {
+		return nil
+	}
+	if info, serr := NewFS(c, root).Stat(name); serr == nil && info.IsDir() {
+		return nil
+	}
+	return err
+}
+
+// runFileTasks runs fn for every task over up to concurrency connections: c itself
+// and connections cloned from it. fn fills in the bytes and retries of its item.
+// Tasks not started because ctx is done are reported as skipped.
+func (c *FtpServerConn) runFileTasks(ctx context.Context, tasks []fileTask, concurrency int, fn func(ctx context.Context, conn *FtpServerConn, t fileTask, item *BatchItem) error) *BatchResult {
+	start := time.Now()
+	result := &BatchResult{Items: make([]BatchItem, len(tasks))}
+	for i, t := range tasks {
+		result.Items[i] = BatchItem{Remote: t.remote, Local: t.local, Status: BatchSkipped}
+	}
+
+	if concurrency > len(tasks) {
+		concurrency = len(tasks)
+	}
+
+	conns := []*FtpServerConn{c}
+	for len(conns) < concurrency {
+		clone, err := c.Clone(ctx)
+		if err != nil {
+			// carry on with the connections we have
+			c.errorf("clone failed: %v", err)
+			break
+		}
+		defer clone.Quit()
+		conns = append(conns, clone)
+	}
+
+	// each worker owns the items it takes from the queue
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for _, conn := range conns {
+		wg.Add(1)
+		go func(conn *FtpServerConn) {
+			defer wg.Done()
+			for i := range queue {
+				item := &result.Items[i]
+				started := time.Now()
+				item.Err = fn(ctx, conn, tasks[i], item)
+				item.Duration = time.Since(started)
+				item.Status = BatchOK
+				if item.Err != nil {
+					item.Status = BatchFailed
+				}
+			}
+		}(conn)
+	}
+
+	for i := range tasks {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		for i := range result.Items {
+			if result.Items[i].Status == BatchSkipped {
+				result.Items[i].Err = err
+			}
+		}
+	}
+	result.Duration = time.Since(start)
+	return result
+}
@@ -0,0 +1,40 @@
+package ftpclient
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUploadDirMapsNames(t *testing.T) {
+	addr, fs := memServer(t, nil, func(s *testSession, cmd string) bool {
+		if strings.HasPrefix(cmd, "MKD ") {
+			s.reply("257 \"%s\" created", strings.TrimPrefix(cmd, "MKD "))
+			return true
+		}
+		return false
+	})
+	// the mapper is not idempotent, so that a name mapped twice shows
+	c := dialTestServer(t, addr, NewConfig().WithNameMapper(func(name string) string {
+		return name + "_"
+	}))
+
+	local := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(local, "sub"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "sub", "a.txt"), []byte("data"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.UploadDir(local, "up"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fs.file("up/sub_/a.txt_"); !ok {
+		t.Errorf("up/sub_/a.txt_ not uploaded, have %v", fs.commands("STOR "))
+	}
+	if mkd := fs.commands("MKD "); len(mkd) != 2 || mkd[1] != "MKD up/sub_" {
+		t.Errorf("directories = %v, want up and up/sub_", mkd)
+	}
+}
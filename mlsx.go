@@ -0,0 +1,57 @@
+package ftpclient
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+)
+
+// Mlsd issues a MLSD FTP command and returns the parsed entries of the directory.
+// The current directory is listed when path is omitted.
+func (c *FtpServerConn) Mlsd(path ...string) (entries []*MlsEntry, err error) {
+	cmd := append([]string{"MLSD"}, path...)
+	conn, err := c.transferCmd(strings.Join(cmd, " "))
+	if err != nil {
+		return
+	}
+
+	r := c.newListingConn(conn)
+	defer func() {
+		if cerr := r.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := c.listingLine(strings.TrimRight(scanner.Text(), "\r"))
+		if line == "" {
+			continue
+		}
+		entry, err := parseMlsxLine(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Mlst issues a MLST FTP command and returns the parsed facts of path.
+func (c *FtpServerConn) Mlst(path string) (*MlsEntry, error) {
+	_, msg, err := c.SendCmd(ActionOK, "MLST %s", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// the fact line is the only reply line starting with a space
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(line, " ") {
+			return parseMlsxLine(strings.TrimRight(line[1:], "\r"))
+		}
+	}
+	return nil, errors.New("No facts in MLST response: " + msg)
+}
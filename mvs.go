@@ -0,0 +1,174 @@
+package ftpclient
+
+import (
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MVSDataset returns the fully qualified MVS dataset name name in single quotes, as
+// z/OS FTP servers expect them, e.g. 'USER.DATA.CNTL(MEMBER)'. Unquoted names are
+// otherwise prefixed with the login user ID.
+func MVSDataset(name string) string {
+	return "'" + strings.Trim(name, "'") + "'"
+}
+
+// Space allocation units of a DatasetAttrs.
+const (
+	SpaceCylinders = "CYL"
+	SpaceTracks    = "TRACKS"
+	SpaceBlocks    = "BLOCKS"
+)
+
+// DatasetAttrs are the allocation attributes of a new MVS dataset, sent with SITE
+// before it is stored. Zero fields are not sent, keeping the server defaults.
+type DatasetAttrs struct {
+	RecFM     string // record format, e.g. "FB" or "VB"
+	LRecL     int    // logical record length
+	BlkSize   int    // block size
+	Space     string // allocation unit: SpaceCylinders, SpaceTracks or SpaceBlocks
+	Primary   int    // primary space quantity
+	Secondary int    // secondary space quantity
+}
+
+// siteParams returns the SITE parameters of a, e.g. "RECFM=FB LRECL=80 CYL PRI=5 SEC=1".
+func (a DatasetAttrs) siteParams() string {
+	var params []string
+	if a.RecFM != "" {
+		params = append(params, "RECFM="+strings.ToUpper(a.RecFM))
+	}
+	if a.LRecL > 0 {
+		params = append(params, "LRECL="+strconv.Itoa(a.LRecL))
+	}
+	if a.BlkSize > 0 {
+		params = append(params, "BLKSIZE="+strconv.Itoa(a.BlkSize))
+	}
+	if a.Space != "" {
+		params = append(params, strings.ToUpper(a.Space))
+	}
+	if a.Primary > 0 {
+		params = append(params, "PRI="+strconv.Itoa(a.Primary))
+	}
+	if a.Secondary > 0 {
+		params = append(params, "SEC="+strconv.Itoa(a.Secondary))
+	}
+	return strings.Join(params, " ")
+}
+
+// SiteDataset issues a SITE FTP command setting the allocation attributes of the
+// datasets stored next.
+func (c *FtpServerConn) SiteDataset(attrs DatasetAttrs) error {
+	params := attrs.siteParams()
+	if params == "" {
+		return nil
+	}
+	code, msg, err := c.SendCmd(-1, "SITE %s", params)
+	if err != nil {
+		return err
+	}
+	if code < 200 || code > 299 {
+		return &textproto.Error{Code: code, Msg: msg}
+	}
+	return nil
+}
+
+// StorDataset allocates the MVS dataset with attrs and stores local into it.
+// dataset is fully qualified and quoted with MVSDataset.
+func (c *FtpServerConn) StorDataset(local, dataset string, attrs DatasetAttrs) error {
+	if err := c.SiteDataset(attrs); err != nil {
+		return err
+	}
+	return c.storFileRetry(local, MVSDataset(dataset))
+}
+
+// parseMVSDatasetFormat parses a line of a z/OS catalog listing:
+//
+//	Volume Unit    Referred Ext Used Recfm Lrecl BlkSz Dsorg Dsname
+//	WYPRES 3390   2019/11/20  1   15  FB      80 27920  PO  ISPF.ISPPROF
+//	Migrated                                                OLD.DATA
+//	Pseudo Directory                                        SUBDIR
+//
+// Partitioned datasets and pseudo directories are reported as directories, with the
+// referred date as modification time. The size is unknown and left zero.
+func parseMVSDatasetFormat(input string) (os.FileInfo, error) {
+	fields := strings.Fields(input)
+	switch {
+	case len(fields) == 2 && fields[0] == "Migrated":
+		return &fileInfo{name: fields[1], raw: input}, nil
+	case len(fields) == 3 && fields[0] == "Pseudo" && fields[1] == "Directory":
+		return &fileInfo{name: fields[2], mode: os.ModeDir, raw: input}, nil
+	case len(fields) != 10:
+		return nil, ErrUnknownFormat
+	}
+
+	var mtime time.Time
+	if fields[2] != "**NONE**" {
+		var err error
+		if mtime, err = time.Parse("2006/01/02", fields[2]); err != nil {
+			return nil, ErrUnknownFormat
+		}
+	}
+
+	var mode os.FileMode
+	if strings.HasPrefix(fields[8], "PO") {
+		mode |= os.ModeDir
+	}
+	return &fileInfo{name: fields[9], mode: mode, mtime: mtime, raw: input, local: true}, nil
+}
+
+// parseMVSMemberFormat parses a line of a z/OS PDS member listing:
+//
+//	 Name     VV.MM   Created       Changed      Size  Init   Mod   Id
+//	MEMBER1   01.03 2019/01/02 2020/03/04 10:20   120   100     0 USER01
+//	MEMBER2
+//
+// The changed time is the modification time. Size counts records, not bytes, and is
+// left zero; the raw line is available from Sys.
+func parseMVSMemberFormat(input string) (os.FileInfo, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 1 && isMVSMemberName(fields[0]) {
+		return &fileInfo{name: fields[0], raw: input}, nil
+	}
+	if len(fields) != 9 || !isMVSMemberName(fields[0]) || !isVVMM(fields[1]) {
+		return nil, ErrUnknownFormat
+	}
+
+	mtime, err := time.Parse("2006/01/02 15:04", fields[3]+" "+fields[4])
+	if err != nil {
+		mtime, err = time.Parse("2006/01/02 15:04:05", fields[3]+" "+fields[4])
+	}
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+	return &fileInfo{name: fields[0], mtime: mtime, raw: input, local: true}, nil
+}
+
+// isMVSMemberName reports whether s is a valid PDS member name: one to eight upper
+// case letters, digits or national characters, not starting with a digit.
+func isMVSMemberName(s string) bool {
+	if len(s) == 0 || len(s) > 8 || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '@' || r == '#' || r == '$') {
+			return false
+		}
+	}
+	return true
+}
+
+// isVVMM reports whether s is a version and modification level such as "01.03".
+func isVVMM(s string) bool {
+	return len(s) == 5 && s[2] == '.' && isDigits(s[:2]) && isDigits(s[3:])
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,14 @@
+package ftpclient
+
+import "testing"
+
+func TestDatasetAttrs(t *testing.T) {
+	// go test -v -run TestDatasetAttrs
+	attrs := DatasetAttrs{RecFM: "fb", LRecL: 80, BlkSize: 27920, Space: SpaceCylinders, Primary: 5, Secondary: 1}
+	if params := attrs.siteParams(); params != "RECFM=FB LRECL=80 BLKSIZE=27920 CYL PRI=5 SEC=1" {
+		t.Errorf("siteParams() = %q", params)
+	}
+	if name := MVSDataset("'USER.DATA'"); name != "'USER.DATA'" {
+		t.Errorf("MVSDataset() = %q", name)
+	}
+}
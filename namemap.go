@@ -0,0 +1,129 @@
+package ftpclient
+
+import (
+	"path"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NameMapper transforms the name of a file or directory before it is created on the
+// server, for servers imposing naming constraints. It receives and returns a single
+// path element.
+type NameMapper func(name string) string
+
+// LowerCaseNames maps names to lower case.
+func LowerCaseNames(name string) string {
+	return strings.ToLower(name)
+}
+
+// StripDiacritics replaces accented Latin letters by their base letter, such as
+// "é" by "e" and "ß" by "ss".
+func StripDiacritics(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if s, ok := diacritics[r]; ok {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var diacritics = func() map[rune]string {
+	m := make(map[rune]string)
+	for base, letters := range map[string]string{
+		"A": "ÀÁÂÃÄÅĀĂĄ", "a": "àáâãäåāăą", "C": "ÇĆĈĊČ", "c": "çćĉċč",
+		"D": "ĎĐ", "d": "ďđ", "E": "ÈÉÊËĒĔĖĘĚ", "e": "èéêëēĕėęě",
+		"G": "ĜĞĠĢ", "g": "ĝğġģ", "H": "ĤĦ", "h": "ĥħ", "I": "ÌÍÎÏĨĪĬĮİ", "i": "ìíîïĩīĭįı",
+		"J": "Ĵ", "j": "ĵ", "K": "Ķ", "k": "ķ", "L": "ĹĻĽĿŁ", "l": "ĺļľŀł",
+		"N": "ÑŃŅŇ", "n": "ñńņň", "O": "ÒÓÔÕÖØŌŎŐ", "o": "òóôõöøōŏő",
+		"R": "ŔŖŘ", "r": "ŕŗř", "S": "ŚŜŞŠ", "s": "śŝşš", "T": "ŢŤŦ", "t": "ţťŧ",
+		"U": "ÙÚÛÜŨŪŬŮŰŲ", "u": "ùúûüũūŭůűų", "W": "Ŵ", "w": "ŵ",
+		"Y": "ÝŶŸ", "y": "ýÿŷ", "Z": "ŹŻŽ", "z": "źżž",
+		"AE": "Æ", "ae": "æ", "OE": "Œ", "oe": "œ", "ss": "ß", "TH": "Þ", "th": "þ",
+	} {
+		for _, r := range letters {
+			m[r] = base
+		}
+	}
+	return m
+}()
+
+// ShortNames maps names to MS-DOS 8.3 names for legacy servers: upper case, at most
+// eight characters before a three character extension, with unsupported characters
+// replaced by "_". Distinct names may map to the same short name.
+func ShortNames(name string) string {
+	base, ext := name, ""
+	if i := strings.LastIndex(name, "."); i > 0 {
+		base, ext = name[:i], name[i+1:]
+	}
+
+	base = shortNamePart(base, 8)
+	ext = shortNamePart(ext, 3)
+	if base == "" {
+		base = "_"
+	}
+	if ext == "" {
+		return base
+	}
+	return base + "." + ext
+}
+
+func shortNamePart(s string, max int) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(StripDiacritics(s)) {
+		if b.Len() == max {
+			break
+		}
+		switch {
+		case r == ' ' || r == '.':
+			// dropped
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("!#$%&'()-@^_`{}~", r)):
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// DatePrefix returns a NameMapper prefixing names with the current date formatted
+// with layout, such as "2006-01-02_".
+func DatePrefix(layout string) NameMapper {
+	return func(name string) string {
+		return time.Now().Format(layout) + name
+	}
+}
+
+// ChainNames returns a NameMapper applying mappers in order.
+func ChainNames(mappers ...NameMapper) NameMapper {
+	return func(name string) string {
+		for _, m := range mappers {
+			name = m(name)
+		}
+		return name
+	}
+}
+
+// mapName applies the configured NameMapper to the last element of remote.
+func (c *FtpServerConn) mapName(remote string) string {
+	if c.nameMapper == nil {
+		return remote
+	}
+	dir, name := path.Split(remote)
+	return dir + c.nameMapper(name)
+}
+
+// mapPath applies the configured NameMapper to every element of a relative path.
+func (c *FtpServerConn) mapPath(rel string) string {
+	if c.nameMapper == nil || rel == "" {
+		return rel
+	}
+	elems := strings.Split(rel, "/")
+	for i, elem := range elems {
+		elems[i] = c.nameMapper(elem)
+	}
+	return strings.Join(elems, "/")
+}
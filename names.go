@@ -0,0 +1,72 @@
+package ftpclient
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"strings"
+)
+
+// NameStream lists path with NLST, which is much cheaper than LIST on huge
+// directories, and calls fn for each name as it arrives. LIST is used when the
+// server does not implement NLST. Returning an error from fn stops the listing and
+// NameStream returns that error.
+func (c *FtpServerConn) NameStream(dir string, fn func(name string) error) (err error) {
+	var args []string
+	if dir != "" {
+		args = append(args, dir)
+	}
+
+	r, err := c.NlstRequest(args...)
+	nameOf := func(line string) (string, bool) {
+		// some servers return the path given to NLST as prefix
+		return path.Base(line), line != ""
+	}
+	if isNotImplemented(err) {
+		r, err = c.ListRequest(args...)
+		nameOf = func(line string) (string, bool) {
+			info, err := c.parse(line)
+			if err != nil {
+				return "", false
+			}
+			return info.Name(), true
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := r.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	return scanNames(r, func(line string) (string, bool) {
+		return nameOf(c.listingLine(line))
+	}, fn)
+}
+
+// NameCount returns the number of names NameStream would report for dir, without
+// keeping them in memory.
+func (c *FtpServerConn) NameCount(dir string) (int, error) {
+	n := 0
+	err := c.NameStream(dir, func(name string) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func scanNames(r io.Reader, nameOf func(line string) (string, bool), fn func(name string) error) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, ok := nameOf(strings.TrimRight(scanner.Text(), "\r"))
+		if !ok || name == "." || name == ".." {
+			continue
+		}
+		if err := fn(name); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,29 @@
+package ftpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrDataPeerMismatch is returned when a data connection does not come from, or go
+// to, the server of the control connection, which may indicate a bounce or hijack attempt.
+var ErrDataPeerMismatch = errors.New("data connection peer does not match the control connection peer")
+
+// verifyDataPeer checks that the remote IP of the data connection conn is the remote
+// IP of the control connection. Connections without IP addresses are not checked.
+func (c *FtpServerConn) verifyDataPeer(conn net.Conn) error {
+	control, ok := c.conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return nil
+	}
+	data, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return nil
+	}
+	if !data.IP.Equal(control.IP) {
+		c.warnf("data connection peer %s does not match control connection peer %s", data.IP, control.IP)
+		return fmt.Errorf("%w: %s, expected %s", ErrDataPeerMismatch, data.IP, control.IP)
+	}
+	return nil
+}
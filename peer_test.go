@@ -0,0 +1,54 @@
+package ftpclient
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestVerifyDataPeer(t *testing.T) {
+	// go test -v -run TestVerifyDataPeer
+	cases := []struct {
+		Name   string
+		Host   string // address of the passive listener
+		Verify bool
+		Err    error
+	}{
+		{"same peer", "127.0.0.1", true, nil},
+		{"foreign peer", "127.0.0.2", true, ErrDataPeerMismatch},
+		{"not verified", "127.0.0.2", false, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr, fs := memServer(t, map[string]string{"file": "data"}, func(s *testSession, cmd string) bool {
+				if cmd != "PASV" {
+					return false
+				}
+				var err error
+				if s.data, err = net.Listen("tcp", tc.Host+":0"); err != nil {
+					s.reply("425 no listener")
+					return true
+				}
+				port := s.data.Addr().(*net.TCPAddr).Port
+				ip := net.ParseIP(tc.Host).To4()
+				s.reply("227 Entering Passive Mode (%d,%d,%d,%d,%d,%d).", ip[0], ip[1], ip[2], ip[3], port/256, port%256)
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig().WithPreferEPSV(false).WithVerifyDataPeer(tc.Verify))
+
+			_, err := c.RetrTo("file", io.Discard, 0)
+			if !errors.Is(err, tc.Err) {
+				t.Fatalf("err = %v, want %v", err, tc.Err)
+			}
+			// a rejected data connection is closed before RETR is sent
+			if retr := len(fs.commands("RETR ")); (retr == 0) != (tc.Err != nil) {
+				t.Errorf("sent RETR %d times", retr)
+			}
+			if err = c.Noop(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
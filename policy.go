@@ -0,0 +1,24 @@
+package ftpclient
+
+import "fmt"
+
+// ReplyPolicy is called with the 220 greeting banner and the 230 login reply.
+// Returning a non-nil error aborts the session, for example when the banner
+// announces maintenance or carries a legal notice that must be rejected.
+type ReplyPolicy func(code int, msg string) error
+
+// PolicyError is returned when a ReplyPolicy rejected the session.
+type PolicyError struct {
+	Code int
+	Msg  string
+	Err  error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("session rejected by policy on %d %s: %v", e.Code, e.Msg, e.Err)
+}
+
+// Unwrap returns the error returned by the policy.
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
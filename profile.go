@@ -0,0 +1,87 @@
+package ftpclient
+
+import (
+	"net"
+	"path"
+	"strings"
+)
+
+// ProfileStore maps host patterns to Config overrides, so that per-partner settings
+// for many heterogeneous servers can be kept in one place.
+type ProfileStore struct {
+	profiles []profile
+}
+
+type profile struct {
+	pattern  string
+	override func(cfg *Config)
+}
+
+// NewProfileStore ...
+func NewProfileStore() *ProfileStore {
+	return &ProfileStore{}
+}
+
+// Add registers an override for hosts matching pattern, returning the ProfileStore
+// pointer for chaining. The pattern uses path.Match syntax, e.g. "*.example.com",
+// and is matched case-insensitively against the host name.
+func (s *ProfileStore) Add(pattern string, override func(cfg *Config)) *ProfileStore {
+	s.profiles = append(s.profiles, profile{
+		pattern:  strings.ToLower(pattern),
+		override: override,
+	})
+	return s
+}
+
+// Config returns a copy of base with the overrides of every profile matching addr
+// applied in registration order. addr may be a host name or a "host:port" address.
+// The tls.Config, proxy header, policies, charsets and slices of base are copied, so
+// that profiles never share them; rate limiters, the circuit breaker, metrics and
+// tracer are safe for concurrent use and stay shared, so that limits and circuit
+// states span every connection.
+func (s *ProfileStore) Config(addr string, base *Config) *Config {
+	cfg := base.clone()
+	host := addr
+	if h, _, err := net.SplitHostPort(addr); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+
+	for _, p := range s.profiles {
+		if ok, _ := path.Match(p.pattern, host); ok {
+			p.override(cfg)
+		}
+	}
+	return cfg
+}
+
+// New returns a connection configured for addr. See Config.
+func (s *ProfileStore) New(addr string, base *Config) *FtpServerConn {
+	return New(s.Config(addr, base))
+}
+
+// clone returns a copy of c sharing none of its mutable settings.
+func (c *Config) clone() *Config {
+	cfg := *c
+	cfg.tlsConfig = c.tlsConfig.Clone()
+	if c.proxyHeader != nil {
+		header := *c.proxyHeader
+		cfg.proxyHeader = &header
+	}
+	if c.resumePolicy != nil {
+		policy := *c.resumePolicy
+		cfg.resumePolicy = &policy
+	}
+	if backoff, ok := c.retryPolicy.(*ExponentialBackoff); ok {
+		policy := *backoff
+		cfg.retryPolicy = &policy
+	}
+	if c.charset != nil {
+		charset := *c.charset
+		cfg.charset = &charset
+	}
+	cfg.transferCompleteCodes = append([]int(nil), c.transferCompleteCodes...)
+	cfg.charsetCandidates = append([]Charset(nil), c.charsetCandidates...)
+	cfg.listParsers = append([]ListParser(nil), c.listParsers...)
+	return &cfg
+}
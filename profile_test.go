@@ -0,0 +1,81 @@
+package ftpclient
+
+import (
+	"crypto/tls"
+	"testing"
+	"time"
+)
+
+func TestProfileStore(t *testing.T) {
+	store := NewProfileStore().
+		Add("*.example.com", func(cfg *Config) {
+			cfg.WithPassive(true).WithCharset(ShiftJIS)
+		}).
+		Add("legacy.example.com", func(cfg *Config) {
+			cfg.WithPassive(false).WithEpsvAll(true)
+		})
+
+	cases := []struct {
+		Addr    string
+		Passive bool
+		EpsvAll bool
+		Charset string
+	}{
+		{"ftp.example.com:21", true, false, ShiftJIS.Name},
+		{"FTP.Example.COM", true, false, ShiftJIS.Name},
+		{"legacy.example.com:990", false, true, ShiftJIS.Name},
+		{"example.com", false, false, ""},
+		{"ftp.example.org:21", false, false, ""},
+	}
+
+	base := NewConfig().WithTLSConfig(&tls.Config{ServerName: "base"})
+	for _, tc := range cases {
+		cfg := store.Config(tc.Addr, base)
+		if cfg.passive != tc.Passive || cfg.epsvAll != tc.EpsvAll {
+			t.Errorf("%s: passive %v, EPSV ALL %v, want %v, %v", tc.Addr, cfg.passive, cfg.epsvAll, tc.Passive, tc.EpsvAll)
+		}
+		var charset string
+		if cfg.charset != nil {
+			charset = cfg.charset.Name
+		}
+		if charset != tc.Charset {
+			t.Errorf("%s: charset %q, want %q", tc.Addr, charset, tc.Charset)
+		}
+	}
+	if base.passive || base.epsvAll || base.charset != nil {
+		t.Error("overrides modified the base config")
+	}
+}
+
+func TestProfileStoreCopiesConfig(t *testing.T) {
+	limiter := NewRateLimiter(1024)
+	breaker := NewCircuitBreaker(3, time.Minute)
+	base := NewConfig().
+		WithTLSConfig(&tls.Config{ServerName: "base"}).
+		WithResumePolicy(&ResumePolicy{MinBytes: 1}).
+		WithRetryPolicy(&ExponentialBackoff{Attempts: 5}).
+		WithTransferCompleteCodes(226).
+		WithDownloadLimiter(limiter).
+		WithCircuitBreaker(breaker)
+	store := NewProfileStore().Add("*", func(cfg *Config) {})
+
+	a := store.Config("a.example.com", base)
+	b := store.Config("b.example.com", base)
+	for _, cfg := range []*Config{a, b} {
+		if cfg.tlsConfig == base.tlsConfig || cfg.tlsConfig.ServerName != "base" {
+			t.Error("tls.Config is shared with the base config")
+		}
+		if cfg.resumePolicy == base.resumePolicy || cfg.retryPolicy == base.retryPolicy {
+			t.Error("policies are shared with the base config")
+		}
+		if &cfg.transferCompleteCodes[0] == &base.transferCompleteCodes[0] {
+			t.Error("slices are shared with the base config")
+		}
+		if cfg.downloadLimiter != limiter || cfg.circuitBreaker != breaker {
+			t.Error("limiter or circuit breaker is not shared")
+		}
+	}
+	if a.tlsConfig == b.tlsConfig {
+		t.Error("tls.Config is shared between profiles")
+	}
+}
@@ -0,0 +1,75 @@
+package ftpclient
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ProgressFunc is called periodically while data moves over a data connection, and
+// once more when the transfer completes. total is -1 when the size is unknown.
+type ProgressFunc func(bytesTransferred, total int64)
+
+// progressInterval is the minimum time between two progress calls.
+const progressInterval = 100 * time.Millisecond
+
+// regexp150Size matches the size announced by many servers in their 150 reply,
+// e.g. "150 Opening BINARY mode data connection for file.bin (1048576 bytes)".
+var regexp150Size = regexp.MustCompile(`\((\d+) bytes\)`)
+
+// parse150Size returns the transfer size announced in a 150 reply, or -1.
+func parse150Size(msg string) int64 {
+	matches := regexp150Size.FindStringSubmatch(msg)
+	if matches == nil {
+		return -1
+	}
+	n, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// announcedSize returns the size announced by the preliminary reply of the transfer
+// command just sent, or -1.
+func (c *FtpServerConn) announcedSize() int64 {
+	if c.lastCode < 100 || c.lastCode > 199 {
+		return -1
+	}
+	return parse150Size(c.lastMsg)
+}
+
+// setTotal sets the expected size of the transfer carried by w, when it is a data connection.
+func setTotal(w interface{}, total int64) {
+	if d, ok := w.(*FtpDataConn); ok {
+		d.total = total
+	}
+}
+
+// reportProgress calls the ProgressFunc, at most every progressInterval unless final,
+// and logs progress to the transfer log of the current operation.
+func (d *FtpDataConn) reportProgress(final bool) {
+	if d.listing {
+		return
+	}
+	if !final {
+		d.logTransferProgress()
+	}
+	if d.c.progress == nil {
+		return
+	}
+	now := time.Now()
+	if !final && now.Sub(d.reported) < progressInterval {
+		return
+	}
+	d.reported = now
+	d.c.progress(d.n, d.total)
+}
+
+// newListingConn wraps the data connection of a listing, which reports no progress.
+func (c *FtpServerConn) newListingConn(conn net.Conn) *FtpDataConn {
+	d := c.newDataConn(conn)
+	d.listing = true
+	return d
+}
@@ -0,0 +1,33 @@
+package ftpclient
+
+// ProtectionLevel is a data channel protection level set with PROT (RFC 4217).
+type ProtectionLevel string
+
+// Protection levels.
+const (
+	ProtClear   ProtectionLevel = "C"
+	ProtPrivate ProtectionLevel = "P"
+)
+
+// Valid reports whether l is a protection level the client can use.
+func (l ProtectionLevel) Valid() bool {
+	return l == ProtClear || l == ProtPrivate
+}
+
+// protect negotiates the configured protection level, private by default.
+func (c *FtpServerConn) protect() error {
+	if err := c.Pbsz("0"); err != nil {
+		return err
+	}
+	level := c.protLevel
+	if level == "" {
+		level = ProtPrivate
+	}
+	return c.Prot(string(level))
+}
+
+// dataProtected reports whether data connections are wrapped in TLS. Without a PROT
+// command, implicit TLS sessions are assumed to protect them.
+func (c *FtpServerConn) dataProtected() bool {
+	return c.tlsConfig != nil && c.dataProt != ProtClear
+}
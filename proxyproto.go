@@ -0,0 +1,108 @@
+package ftpclient
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// ProxyHeader describes a HAProxy PROXY protocol header sent at the start of the
+// control connection, for servers behind PROXY-protocol-aware load balancers.
+// Version is 1 (text) or 2 (binary). SourceAddr and DestAddr default to the local
+// and remote addresses of the connection when nil.
+type ProxyHeader struct {
+	Version    int
+	SourceAddr net.Addr
+	DestAddr   net.Addr
+}
+
+var proxyV2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// write sends the header on conn.
+func (h *ProxyHeader) write(conn net.Conn) error {
+	src, dst := h.SourceAddr, h.DestAddr
+	if src == nil {
+		src = conn.LocalAddr()
+	}
+	if dst == nil {
+		dst = conn.RemoteAddr()
+	}
+
+	var header []byte
+	var err error
+	switch h.Version {
+	case 0, 1:
+		header, err = proxyHeaderV1(src, dst)
+	case 2:
+		header, err = proxyHeaderV2(src, dst)
+	default:
+		err = fmt.Errorf("unsupported PROXY protocol version: %d", h.Version)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(header)
+	return err
+}
+
+// proxyAddrs returns the TCP addresses of src and dst in a single address family, as
+// a header carries one: IPv4 when both are IPv4, otherwise IPv6 with IPv4 addresses
+// mapped to IPv6.
+func proxyAddrs(src, dst net.Addr) (netip.AddrPort, netip.AddrPort, error) {
+	s, ok1 := src.(*net.TCPAddr)
+	d, ok2 := dst.(*net.TCPAddr)
+	if !ok1 || !ok2 {
+		return netip.AddrPort{}, netip.AddrPort{}, errors.New("PROXY protocol requires TCP addresses")
+	}
+
+	sa, da := s.AddrPort(), d.AddrPort()
+	if sa.Addr().Unmap().Is4() && da.Addr().Unmap().Is4() {
+		return netip.AddrPortFrom(sa.Addr().Unmap(), sa.Port()), netip.AddrPortFrom(da.Addr().Unmap(), da.Port()), nil
+	}
+	return netip.AddrPortFrom(netip.AddrFrom16(sa.Addr().As16()), sa.Port()), netip.AddrPortFrom(netip.AddrFrom16(da.Addr().As16()), da.Port()), nil
+}
+
+// proxyHeaderV1 builds a human-readable PROXY protocol header.
+func proxyHeaderV1(src, dst net.Addr) ([]byte, error) {
+	s, d, err := proxyAddrs(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	proto := "TCP6"
+	if s.Addr().Is4() {
+		proto = "TCP4"
+	}
+	return []byte(fmt.Sprintf("PROXY %s %s %s %d %d\r\n", proto, s.Addr(), d.Addr(), s.Port(), d.Port())), nil
+}
+
+// proxyHeaderV2 builds a binary PROXY protocol header.
+func proxyHeaderV2(src, dst net.Addr) ([]byte, error) {
+	s, d, err := proxyAddrs(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(proxyV2Signature)
+	// version 2, PROXY command
+	buf.WriteByte(0x21)
+	if s.Addr().Is4() {
+		// AF_INET, STREAM
+		buf.WriteByte(0x11)
+		binary.Write(&buf, binary.BigEndian, uint16(12))
+	} else {
+		// AF_INET6, STREAM
+		buf.WriteByte(0x21)
+		binary.Write(&buf, binary.BigEndian, uint16(36))
+	}
+	buf.Write(s.Addr().AsSlice())
+	buf.Write(d.Addr().AsSlice())
+	binary.Write(&buf, binary.BigEndian, s.Port())
+	binary.Write(&buf, binary.BigEndian, d.Port())
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+package ftpclient
+
+import (
+	"encoding/hex"
+	"net"
+	"testing"
+)
+
+func TestProxyHeaderV1(t *testing.T) {
+	cases := []struct {
+		Src, Dst string
+		Want     string
+	}{
+		{"192.0.2.1", "198.51.100.2", "PROXY TCP4 192.0.2.1 198.51.100.2 40000 21\r\n"},
+		{"::ffff:192.0.2.1", "198.51.100.2", "PROXY TCP4 192.0.2.1 198.51.100.2 40000 21\r\n"},
+		{"2001:db8::1", "2001:db8::2", "PROXY TCP6 2001:db8::1 2001:db8::2 40000 21\r\n"},
+		{"192.0.2.1", "2001:db8::2", "PROXY TCP6 ::ffff:192.0.2.1 2001:db8::2 40000 21\r\n"},
+		{"2001:db8::1", "198.51.100.2", "PROXY TCP6 2001:db8::1 ::ffff:198.51.100.2 40000 21\r\n"},
+	}
+
+	for _, tc := range cases {
+		src := &net.TCPAddr{IP: net.ParseIP(tc.Src), Port: 40000}
+		dst := &net.TCPAddr{IP: net.ParseIP(tc.Dst), Port: 21}
+		header, err := proxyHeaderV1(src, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(header) != tc.Want {
+			t.Errorf("%s %s: header %q, want %q", tc.Src, tc.Dst, header, tc.Want)
+		}
+	}
+}
+
+func TestProxyHeaderV2(t *testing.T) {
+	const signature = "0d0a0d0a000d0a515549540a"
+	cases := []struct {
+		Src, Dst string
+		Want     string
+	}{
+		{"192.0.2.1", "198.51.100.2", signature + "21" + "11" + "000c" +
+			"c0000201" + "c6336402" + "9c40" + "0015"},
+		{"2001:db8::1", "2001:db8::2", signature + "21" + "21" + "0024" +
+			"20010db8000000000000000000000001" + "20010db8000000000000000000000002" + "9c40" + "0015"},
+		{"192.0.2.1", "2001:db8::2", signature + "21" + "21" + "0024" +
+			"00000000000000000000ffffc0000201" + "20010db8000000000000000000000002" + "9c40" + "0015"},
+	}
+
+	for _, tc := range cases {
+		src := &net.TCPAddr{IP: net.ParseIP(tc.Src), Port: 40000}
+		dst := &net.TCPAddr{IP: net.ParseIP(tc.Dst), Port: 21}
+		header, err := proxyHeaderV2(src, dst)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(header); got != tc.Want {
+			t.Errorf("%s %s: header\n%s, want\n%s", tc.Src, tc.Dst, got, tc.Want)
+		}
+	}
+}
+
+func TestProxyHeaderErrors(t *testing.T) {
+	udp := &net.UDPAddr{IP: net.ParseIP("192.0.2.1"), Port: 40000}
+	tcp := &net.TCPAddr{IP: net.ParseIP("198.51.100.2"), Port: 21}
+	if _, err := proxyHeaderV1(udp, tcp); err == nil {
+		t.Error("v1 header built for a UDP address")
+	}
+	if _, err := proxyHeaderV2(tcp, udp); err == nil {
+		t.Error("v2 header built for a UDP address")
+	}
+}
@@ -0,0 +1,75 @@
+package ftpclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles data transfers. WaitN blocks until n bytes may be
+// transferred. It is satisfied by *rate.Limiter from golang.org/x/time/rate, so a
+// single limiter can be shared to enforce one limit across many connections.
+type RateLimiter interface {
+	WaitN(ctx context.Context, n int) error
+}
+
+// NewRateLimiter returns a token bucket RateLimiter allowing bytesPerSecond, with a
+// burst of one second worth of bytes. It is safe for concurrent use.
+func NewRateLimiter(bytesPerSecond int) RateLimiter {
+	if bytesPerSecond < 1 {
+		bytesPerSecond = 1
+	}
+	return &tokenBucket{
+		rate:   float64(bytesPerSecond),
+		burst:  bytesPerSecond,
+		tokens: float64(bytesPerSecond),
+		last:   time.Now(),
+	}
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+// Burst returns the largest n WaitN should be called with.
+func (b *tokenBucket) Burst() int {
+	return b.burst
+}
+
+// WaitN takes n tokens, going into debt and sleeping until it is repaid.
+func (b *tokenBucket) WaitN(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > float64(b.burst) {
+		b.tokens = float64(b.burst)
+	}
+	b.last = now
+	b.tokens -= float64(n)
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// limitChunk caps buf to the burst of limiter, as rate.Limiter refuses larger waits.
+func limitChunk(limiter RateLimiter, buf []byte) []byte {
+	if b, ok := limiter.(interface{ Burst() int }); ok && b.Burst() > 0 && len(buf) > b.Burst() {
+		return buf[:b.Burst()]
+	}
+	return buf
+}
@@ -0,0 +1,64 @@
+package ftpclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	// go test -v -run TestTokenBucket
+	limiter := NewRateLimiter(10000)
+	ctx := context.Background()
+
+	// the bucket starts full with a burst of one second
+	start := time.Now()
+	if err := limiter.WaitN(ctx, 10000); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("WaitN(burst) took %v", elapsed)
+	}
+
+	// the next 1000 bytes are repaid at 10000 bytes per second
+	start = time.Now()
+	if err := limiter.WaitN(ctx, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond || elapsed > time.Second {
+		t.Errorf("WaitN(1000) took %v, want about 100ms", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := limiter.WaitN(canceled, 10000); err != context.Canceled {
+		t.Errorf("WaitN() = %v with a canceled context", err)
+	}
+}
+
+// unboundedLimiter is a RateLimiter without a burst.
+type unboundedLimiter struct{}
+
+func (unboundedLimiter) WaitN(ctx context.Context, n int) error {
+	return nil
+}
+
+func TestLimitChunk(t *testing.T) {
+	// go test -v -run TestLimitChunk
+	buf := make([]byte, 5000)
+	cases := []struct {
+		Limiter RateLimiter
+		Len     int
+	}{
+		{NewRateLimiter(1000), 1000},
+		{NewRateLimiter(8000), 5000},
+		{NewRateLimiter(0), 1},
+		{unboundedLimiter{}, 5000},
+	}
+
+	for _, c := range cases {
+		if n := len(limitChunk(c.Limiter, buf)); n != c.Len {
+			t.Errorf("limitChunk(%T) = %d bytes, want %d", c.Limiter, n, c.Len)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package ftpclient
+
+import "path"
+
+// RemoveAll removes path and any children it contains. Files are deleted with DELE
+// and directories are removed bottom-up with RMD, since RMD fails on non-empty
+// directories. When a trash directory is configured, path is moved there instead.
+func (c *FtpServerConn) RemoveAll(name string) error {
+	if c.trashes(name) {
+		return c.moveToTrash(name)
+	}
+	return c.removeAll(name)
+}
+
+func (c *FtpServerConn) removeAll(name string) error {
+	// a plain file is removed with a single command
+	if err := c.dele(name); err == nil {
+		return nil
+	}
+
+	infos, err := c.readDir(name)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		child := path.Join(name, info.Name())
+		if info.IsDir() {
+			err = c.removeAll(child)
+		} else {
+			err = c.dele(child)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.Rmd(name)
+}
@@ -0,0 +1,191 @@
+// Package replycode interprets FTP reply codes as RFC 959 section 4.2 defines them:
+// the first digit tells whether a command succeeded, the second the kind of reply.
+// It is used by ftpclient itself and is handy when checking the codes returned by
+// SendCmd.
+package replycode
+
+import (
+	"strconv"
+)
+
+// Class is the meaning of the first digit of a reply code.
+type Class int
+
+// Reply classes.
+const (
+	Invalid Class = iota
+	PositivePreliminary
+	PositiveCompletion
+	PositiveIntermediate
+	TransientNegative
+	PermanentNegative
+)
+
+var classNames = map[Class]string{
+	Invalid:              "invalid",
+	PositivePreliminary:  "positive preliminary",
+	PositiveCompletion:   "positive completion",
+	PositiveIntermediate: "positive intermediate",
+	TransientNegative:    "transient negative completion",
+	PermanentNegative:    "permanent negative completion",
+}
+
+// String returns the name of the class.
+func (c Class) String() string {
+	if name, ok := classNames[c]; ok {
+		return name
+	}
+	return "Class(" + strconv.Itoa(int(c)) + ")"
+}
+
+// Category returns the class of code, or Invalid when code is not a reply code.
+func Category(code int) Class {
+	if code < 100 || code > 599 {
+		return Invalid
+	}
+	return Class(code / 100)
+}
+
+// IsPositivePreliminary reports whether code is a 1yz reply: the action is being
+// started and another reply follows.
+func IsPositivePreliminary(code int) bool {
+	return Category(code) == PositivePreliminary
+}
+
+// IsPositiveCompletion reports whether code is a 2yz reply: the action succeeded.
+func IsPositiveCompletion(code int) bool {
+	return Category(code) == PositiveCompletion
+}
+
+// IsPositiveIntermediate reports whether code is a 3yz reply: the command was
+// accepted and another command is expected, such as PASS after USER.
+func IsPositiveIntermediate(code int) bool {
+	return Category(code) == PositiveIntermediate
+}
+
+// IsTransientNegative reports whether code is a 4yz reply: the action failed but
+// may succeed when retried.
+func IsTransientNegative(code int) bool {
+	return Category(code) == TransientNegative
+}
+
+// IsPermanentNegative reports whether code is a 5yz reply: the action failed and
+// retrying it unchanged will fail again.
+func IsPermanentNegative(code int) bool {
+	return Category(code) == PermanentNegative
+}
+
+// IsNegative reports whether code is a 4yz or 5yz reply.
+func IsNegative(code int) bool {
+	return IsTransientNegative(code) || IsPermanentNegative(code)
+}
+
+// IsNotImplemented reports whether code means that the command or its parameter is
+// not implemented or not understood: 500, 502 or 504.
+func IsNotImplemented(code int) bool {
+	return code == 500 || code == 502 || code == 504
+}
+
+// Function is the meaning of the second digit of a reply code.
+type Function int
+
+// Reply functions.
+const (
+	Syntax Function = iota
+	Information
+	Connections
+	Authentication
+	Unspecified
+	FileSystem
+)
+
+var functionNames = map[Function]string{
+	Syntax:         "syntax",
+	Information:    "information",
+	Connections:    "connections",
+	Authentication: "authentication and accounting",
+	Unspecified:    "unspecified",
+	FileSystem:     "file system",
+}
+
+// String returns the name of the function.
+func (f Function) String() string {
+	if name, ok := functionNames[f]; ok {
+		return name
+	}
+	return "Function(" + strconv.Itoa(int(f)) + ")"
+}
+
+// FunctionOf returns the function of code, as given by its second digit.
+func FunctionOf(code int) Function {
+	return Function(code / 10 % 10)
+}
+
+var descriptions = map[int]string{
+	110: "Restart marker reply",
+	120: "Service ready in a few minutes",
+	125: "Data connection already open; transfer starting",
+	150: "File status okay; about to open data connection",
+	200: "Command okay",
+	202: "Command not implemented, superfluous at this site",
+	211: "System status, or system help reply",
+	212: "Directory status",
+	213: "File status",
+	214: "Help message",
+	215: "NAME system type",
+	220: "Service ready for new user",
+	221: "Service closing control connection",
+	225: "Data connection open; no transfer in progress",
+	226: "Closing data connection; requested file action successful",
+	227: "Entering Passive Mode",
+	228: "Entering Long Passive Mode",
+	229: "Entering Extended Passive Mode",
+	230: "User logged in, proceed",
+	232: "User logged in, authorized by security data exchange",
+	234: "Security data exchange complete",
+	250: "Requested file action okay, completed",
+	257: "Pathname created",
+	331: "User name okay, need password",
+	332: "Need account for login",
+	334: "Requested security mechanism is ok",
+	336: "Username okay, need password; challenge is given",
+	350: "Requested file action pending further information",
+	421: "Service not available, closing control connection",
+	425: "Can't open data connection",
+	426: "Connection closed; transfer aborted",
+	430: "Invalid username or password",
+	431: "Need some unavailable resource to process security",
+	450: "Requested file action not taken; file unavailable",
+	451: "Requested action aborted: local error in processing",
+	452: "Requested action not taken; insufficient storage space",
+	500: "Syntax error, command unrecognized",
+	501: "Syntax error in parameters or arguments",
+	502: "Command not implemented",
+	503: "Bad sequence of commands",
+	504: "Command not implemented for that parameter",
+	521: "Data connection cannot be opened with this PROT setting",
+	522: "Network protocol not supported",
+	530: "Not logged in",
+	532: "Need account for storing files",
+	533: "Command protection level denied for policy reasons",
+	534: "Request denied for policy reasons",
+	535: "Failed security check",
+	536: "Data protection level not supported by security mechanism",
+	550: "Requested action not taken; file unavailable",
+	551: "Requested action aborted: page type unknown",
+	552: "Requested file action aborted; exceeded storage allocation",
+	553: "Requested action not taken; file name not allowed",
+}
+
+// Description returns the standard meaning of code, as defined by RFC 959 and its
+// extensions, or a description of its class for unknown codes.
+func Description(code int) string {
+	if desc, ok := descriptions[code]; ok {
+		return desc
+	}
+	class := Category(code)
+	if class == Invalid {
+		return "Invalid reply code " + strconv.Itoa(code)
+	}
+	return "Unknown " + class.String() + " reply"
+}
@@ -0,0 +1,33 @@
+package replycode
+
+import (
+	"testing"
+)
+
+func TestCategory(t *testing.T) {
+	// go test -v -run TestCategory
+	cases := []struct {
+		Code  int
+		Class Class
+	}{
+		{150, PositivePreliminary},
+		{226, PositiveCompletion},
+		{350, PositiveIntermediate},
+		{426, TransientNegative},
+		{550, PermanentNegative},
+		{99, Invalid},
+		{600, Invalid},
+	}
+
+	for _, c := range cases {
+		if class := Category(c.Code); class != c.Class {
+			t.Errorf("Category(%d) = %v", c.Code, class)
+		}
+	}
+	if !IsTransientNegative(421) || IsTransientNegative(530) || !IsNotImplemented(502) {
+		t.Errorf("unexpected predicates")
+	}
+	if FunctionOf(530) != Authentication || Description(999) != "Invalid reply code 999" {
+		t.Errorf("unexpected function or description")
+	}
+}
@@ -0,0 +1,135 @@
+package ftpclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TransferType is the representation type set with TYPE (RFC 959 section 3.1.1).
+type TransferType string
+
+// Transfer types. ASCII and EBCDIC may be followed by a format control, e.g. "A N",
+// and the local type by a byte size, e.g. "L 8".
+const (
+	TypeASCII  TransferType = "A"
+	TypeEBCDIC TransferType = "E"
+	TypeBinary TransferType = "I"
+	TypeLocal8 TransferType = "L 8"
+)
+
+// String returns a readable name of the type.
+func (t TransferType) String() string {
+	switch strings.ToUpper(string(t)) {
+	case "A", "A N":
+		return "ASCII"
+	case "E", "E N":
+		return "EBCDIC"
+	case "I":
+		return "binary"
+	}
+	return string(t)
+}
+
+// Valid reports whether t is a type defined by RFC 959.
+func (t TransferType) Valid() bool {
+	fields := strings.Fields(strings.ToUpper(string(t)))
+	if len(fields) == 0 {
+		return false
+	}
+	switch fields[0] {
+	case "A", "E":
+		return len(fields) == 1 || len(fields) == 2 && strings.ContainsAny(fields[1], "NTC") && len(fields[1]) == 1
+	case "I":
+		return len(fields) == 1
+	case "L":
+		if len(fields) != 2 {
+			return false
+		}
+		n, err := strconv.Atoi(fields[1])
+		return err == nil && n > 0
+	}
+	return false
+}
+
+// TransferMode is the transmission mode set with MODE (RFC 959 section 3.4).
+type TransferMode string
+
+// Transfer modes.
+const (
+	ModeStream     TransferMode = "S"
+	ModeBlock      TransferMode = "B"
+	ModeCompressed TransferMode = "C"
+)
+
+// String returns a readable name of the mode.
+func (m TransferMode) String() string {
+	switch strings.ToUpper(string(m)) {
+	case "S":
+		return "stream"
+	case "B":
+		return "block"
+	case "C":
+		return "compressed"
+	}
+	return string(m)
+}
+
+// Valid reports whether m is a mode defined by RFC 959.
+func (m TransferMode) Valid() bool {
+	switch strings.ToUpper(string(m)) {
+	case "S", "B", "C":
+		return true
+	}
+	return false
+}
+
+// FileStructure is the file structure set with STRU (RFC 959 section 3.1.2).
+type FileStructure string
+
+// File structures.
+const (
+	StruFile   FileStructure = "F"
+	StruRecord FileStructure = "R"
+	StruPage   FileStructure = "P"
+)
+
+// String returns a readable name of the structure.
+func (s FileStructure) String() string {
+	switch strings.ToUpper(string(s)) {
+	case "F":
+		return "file"
+	case "R":
+		return "record"
+	case "P":
+		return "page"
+	}
+	return string(s)
+}
+
+// Valid reports whether s is a structure defined by RFC 959.
+func (s FileStructure) Valid() bool {
+	switch strings.ToUpper(string(s)) {
+	case "F", "R", "P":
+		return true
+	}
+	return false
+}
+
+// Mode issues a MODE FTP command.
+func (c *FtpServerConn) Mode(mode TransferMode) error {
+	if !mode.Valid() {
+		return fmt.Errorf("invalid transfer mode: %q", string(mode))
+	}
+	_, _, err := c.SendCmd(CommandOkay, "MODE %s", string(mode))
+	return err
+}
+
+// Stru issues a STRU FTP command.
+func (c *FtpServerConn) Stru(stru FileStructure) error {
+	if !stru.Valid() {
+		return fmt.Errorf("invalid file structure: %q", string(stru))
+	}
+	_, _, err := c.SendCmd(CommandOkay, "STRU %s", string(stru))
+	return err
+}
@@ -0,0 +1,28 @@
+package ftpclient
+
+import "testing"
+
+func TestTransferTypeValid(t *testing.T) {
+	// go test -v -run TestTransferTypeValid
+	cases := []struct {
+		Type  TransferType
+		Valid bool
+	}{
+		{TypeBinary, true},
+		{TypeASCII, true},
+		{"a n", true},
+		{TypeLocal8, true},
+		{"E T", true},
+		{"B", false},
+		{"I N", false},
+		{"L", false},
+		{"A X", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		if valid := c.Type.Valid(); valid != c.Valid {
+			t.Errorf("TransferType(%q).Valid() = %v", string(c.Type), valid)
+		}
+	}
+}
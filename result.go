@@ -0,0 +1,117 @@
+package ftpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Batch item states.
+const (
+	BatchOK      = "ok"
+	BatchFailed  = "failed"
+	BatchSkipped = "skipped"
+)
+
+// BatchItem is the outcome of one file of a multi-file transfer.
+// Retries is the number of attempts made after the first one.
+type BatchItem struct {
+	Remote   string
+	Local    string
+	Status   string
+	Bytes    int64
+	Duration time.Duration
+	Retries  int
+	Err      error
+}
+
+// MarshalJSON encodes the item with its duration in milliseconds and its error as a string.
+func (i BatchItem) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Remote     string `json:"remote"`
+		Local      string `json:"local"`
+		Status     string `json:"status"`
+		Bytes      int64  `json:"bytes"`
+		DurationMs int64  `json:"duration_ms"`
+		Retries    int    `json:"retries"`
+		Error      string `json:"error,omitempty"`
+	}{
+		Remote:     i.Remote,
+		Local:      i.Local,
+		Status:     i.Status,
+		Bytes:      i.Bytes,
+		DurationMs: i.Duration.Milliseconds(),
+		Retries:    i.Retries,
+	}
+	if i.Err != nil {
+		v.Error = i.Err.Error()
+	}
+	return json.Marshal(v)
+}
+
+// BatchResult aggregates the outcome of a multi-file transfer such as DownloadDir.
+// Items are in task order.
+type BatchResult struct {
+	Items    []BatchItem
+	Duration time.Duration
+}
+
+// MarshalJSON encodes the result with a summary of the transferred bytes and failures.
+func (r *BatchResult) MarshalJSON() ([]byte, error) {
+	items := r.Items
+	if items == nil {
+		items = []BatchItem{}
+	}
+	return json.Marshal(struct {
+		Items      []BatchItem `json:"items"`
+		Bytes      int64       `json:"bytes"`
+		Failed     int         `json:"failed"`
+		DurationMs int64       `json:"duration_ms"`
+	}{
+		Items:      items,
+		Bytes:      r.Bytes(),
+		Failed:     len(r.FailedItems()),
+		DurationMs: r.Duration.Milliseconds(),
+	})
+}
+
+// FailedItems returns the items that failed.
+func (r *BatchResult) FailedItems() []BatchItem {
+	return r.filter(BatchFailed)
+}
+
+// SkippedItems returns the items that were not attempted, because the operation was
+// canceled first.
+func (r *BatchResult) SkippedItems() []BatchItem {
+	return r.filter(BatchSkipped)
+}
+
+// Bytes returns the number of bytes transferred by all items.
+func (r *BatchResult) Bytes() int64 {
+	var n int64
+	for _, item := range r.Items {
+		n += item.Bytes
+	}
+	return n
+}
+
+// Err joins the errors of the failed items, or returns nil when every item succeeded.
+func (r *BatchResult) Err() error {
+	var errs []error
+	for _, item := range r.Items {
+		if item.Err != nil {
+			errs = append(errs, item.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *BatchResult) filter(status string) []BatchItem {
+	var items []BatchItem
+	for _, item := range r.Items {
+		if item.Status == status {
+			items = append(items, item)
+		}
+	}
+	return items
+}
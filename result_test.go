@@ -0,0 +1,60 @@
+package ftpclient
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBatchResultJSON(t *testing.T) {
+	// go test -v -run TestBatchResultJSON
+	result := &BatchResult{
+		Items: []BatchItem{
+			{Remote: "a.txt", Local: "/tmp/a.txt", Status: BatchOK, Bytes: 100, Duration: 1500 * time.Millisecond},
+			{Remote: "b.txt", Local: "/tmp/b.txt", Status: BatchFailed, Bytes: 20, Duration: 250 * time.Millisecond, Retries: 2, Err: errors.New("550 denied")},
+			{Remote: "c.txt", Local: "/tmp/c.txt", Status: BatchSkipped},
+		},
+		Duration: 2 * time.Second,
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"items":[` +
+		`{"remote":"a.txt","local":"/tmp/a.txt","status":"ok","bytes":100,"duration_ms":1500,"retries":0},` +
+		`{"remote":"b.txt","local":"/tmp/b.txt","status":"failed","bytes":20,"duration_ms":250,"retries":2,"error":"550 denied"},` +
+		`{"remote":"c.txt","local":"/tmp/c.txt","status":"skipped","bytes":0,"duration_ms":0,"retries":0}` +
+		`],"bytes":120,"failed":1,"duration_ms":2000}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() =\n%s\nwant\n%s", data, want)
+	}
+
+	if data, err = json.Marshal(&BatchResult{}); err != nil || string(data) != `{"items":[],"bytes":0,"failed":0,"duration_ms":0}` {
+		t.Errorf("json.Marshal(empty) = %s, %v", data, err)
+	}
+}
+
+func TestBatchResultItems(t *testing.T) {
+	// go test -v -run TestBatchResultItems
+	failure := errors.New("failure")
+	result := &BatchResult{Items: []BatchItem{
+		{Remote: "a", Status: BatchOK, Bytes: 1},
+		{Remote: "b", Status: BatchFailed, Err: failure},
+		{Remote: "c", Status: BatchSkipped},
+	}}
+
+	if items := result.FailedItems(); len(items) != 1 || items[0].Remote != "b" {
+		t.Errorf("FailedItems() = %v", items)
+	}
+	if items := result.SkippedItems(); len(items) != 1 || items[0].Remote != "c" {
+		t.Errorf("SkippedItems() = %v", items)
+	}
+	if err := result.Err(); !errors.Is(err, failure) {
+		t.Errorf("Err() = %v", err)
+	}
+	if err := (&BatchResult{Items: result.Items[:1]}).Err(); err != nil {
+		t.Errorf("Err() = %v without failures", err)
+	}
+}
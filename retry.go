@@ -0,0 +1,207 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"os"
+	"time"
+
+	"github.com/tsujimic/ftpclient-go/replycode"
+)
+
+// RetryPolicy decides whether a failed operation is retried. ShouldRetry receives
+// the reply code carried by err, or zero for network errors, and the number of
+// attempts made so far, starting at one. It returns the delay before the next attempt
+// and whether to make it at all.
+type RetryPolicy interface {
+	ShouldRetry(code int, err error, attempt int) (delay time.Duration, ok bool)
+}
+
+// ExponentialBackoff is the default RetryPolicy. Attempts is the total number of
+// attempts including the first one. The delay before the n-th retry is Backoff
+// multiplied by Multiplier (default 2) n-1 times, capped at MaxBackoff.
+// Only transient failures are retried: 4xx replies, aborted or stalled transfers and
+// network timeouts or resets.
+type ExponentialBackoff struct {
+	Attempts   int
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	Multiplier float64
+}
+
+// DefaultRetryPolicy makes three attempts, waiting one and then two seconds.
+var DefaultRetryPolicy RetryPolicy = &ExponentialBackoff{
+	Attempts:   3,
+	Backoff:    time.Second,
+	MaxBackoff: 30 * time.Second,
+}
+
+// ResumePolicy describes how retried transfers continue. A failed transfer is
+// resumed with REST when at least MinBytes were transferred, and restarted from the
+// beginning otherwise. With Verify, the remote SIZE is compared with the local size
+// after a resumed transfer.
+type ResumePolicy struct {
+	MinBytes int64
+	Verify   bool
+}
+
+// ShouldRetry implements RetryPolicy.
+func (p *ExponentialBackoff) ShouldRetry(code int, err error, attempt int) (time.Duration, bool) {
+	if attempt >= p.Attempts || !isTransient(err) {
+		return 0, false
+	}
+	return p.delay(attempt), true
+}
+
+// delay returns the backoff before the given retry.
+func (p *ExponentialBackoff) delay(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	d := float64(p.Backoff)
+	for i := 1; i < attempt; i++ {
+		d *= multiplier
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(d)
+}
+
+// retryDo calls fn until it succeeds, policy declines to retry or ctx is done.
+// attempt starts at zero. A nil policy performs a single attempt.
+func retryDo(ctx context.Context, policy RetryPolicy, fn func(attempt int) error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn(attempt)
+		if err == nil || policy == nil {
+			return err
+		}
+
+		delay, ok := policy.ShouldRetry(replyCode(err), err, attempt+1)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// replyCode returns the server reply code carried by err, or zero.
+func replyCode(err error) int {
+	var aborted *TransferAbortedError
+	if errors.As(err, &aborted) {
+		return aborted.Code
+	}
+	var reset *DataConnResetError
+	if errors.As(err, &reset) {
+		return reset.Code
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code
+	}
+	return 0
+}
+
+// isTransient reports whether err is worth retrying.
+func isTransient(err error) bool {
+	var aborted *TransferAbortedError
+	if errors.As(err, &aborted) {
+		return true
+	}
+	var stalled *StalledTransferError
+	if errors.As(err, &stalled) {
+		return true
+	}
+	var reset *DataConnResetError
+	if errors.As(err, &reset) {
+		return replycode.IsTransientNegative(reset.Code)
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return replycode.IsTransientNegative(reply.Code)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || isConnReset(err)
+}
+
+// retry runs fn according to the configured RetryPolicy.
+// Retries are logged to the transfer log of the current operation.
+func (c *FtpServerConn) retry(fn func(attempt int) error) error {
+	var last error
+	return retryDo(c.context(), c.retryPolicy, func(attempt int) error {
+		if c.currentTransferLog() != nil && attempt > 0 {
+			c.infof("retry %d after: %v", attempt, last)
+		}
+		last = fn(attempt)
+		return last
+	})
+}
+
+// resumable reports whether a transfer that reached offset should be resumed.
+func (c *FtpServerConn) resumable(offset int64) bool {
+	return c.resumePolicy != nil && offset > 0 && offset >= c.resumePolicy.MinBytes
+}
+
+// resumeRetrFile resumes a download and verifies it if requested.
+func (c *FtpServerConn) resumeRetrFile(remote, local string) error {
+	if err := c.ResumeRetrFile(remote, local); err != nil {
+		return err
+	}
+	return c.verifyResume(remote, localSize(local))
+}
+
+// resumeStorFile resumes an upload at offset and verifies it if requested.
+func (c *FtpServerConn) resumeStorFile(local, remote string, offset int64) error {
+	file, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = c.StorFrom(remote, file, uint64(offset)); err != nil {
+		return err
+	}
+	return c.verifyResume(remote, localSize(local))
+}
+
+// verifyResume compares the remote size with the local size when the ResumePolicy asks for it.
+func (c *FtpServerConn) verifyResume(remote string, size int64) error {
+	if !c.resumePolicy.Verify {
+		return nil
+	}
+	n, err := c.Size(remote)
+	if err != nil {
+		return err
+	}
+	if int64(n) != size {
+		return fmt.Errorf("resumed transfer size mismatch for %q: remote %d, local %d", remote, n, size)
+	}
+	return nil
+}
+
+// localSize returns the size of a local file, or zero when it cannot be determined.
+func localSize(name string) int64 {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,58 @@
+package ftpclient
+
+import (
+	"errors"
+	"net/textproto"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	// go test -v -run TestExponentialBackoff
+	cases := []struct {
+		Policy ExponentialBackoff
+		Delays []time.Duration
+	}{
+		{ExponentialBackoff{Backoff: time.Second}, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{ExponentialBackoff{Backoff: time.Second, MaxBackoff: 3 * time.Second}, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}},
+		{ExponentialBackoff{Backoff: 100 * time.Millisecond, Multiplier: 3}, []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond}},
+	}
+
+	for _, c := range cases {
+		for i, want := range c.Delays {
+			if got := c.Policy.delay(i + 1); got != want {
+				t.Errorf("%+v: delay(%d) = %v, want %v", c.Policy, i+1, got, want)
+			}
+		}
+	}
+}
+
+func TestExponentialBackoffShouldRetry(t *testing.T) {
+	// go test -v -run TestExponentialBackoffShouldRetry
+	policy := &ExponentialBackoff{Attempts: 3, Backoff: time.Second}
+	cases := []struct {
+		Err     error
+		Attempt int
+		Retry   bool
+	}{
+		{&textproto.Error{Code: 421, Msg: "busy"}, 1, true},
+		{&textproto.Error{Code: 421, Msg: "busy"}, 2, true},
+		{&textproto.Error{Code: 421, Msg: "busy"}, 3, false},
+		{&textproto.Error{Code: 550, Msg: "not found"}, 1, false},
+		{&TransferAbortedError{Code: 426, Msg: "aborted"}, 1, true},
+		{&StalledTransferError{Period: time.Second, MinBytes: 1}, 1, true},
+		{os.ErrDeadlineExceeded, 1, true},
+		{errors.New("other"), 1, false},
+	}
+
+	for _, c := range cases {
+		delay, ok := policy.ShouldRetry(replyCode(c.Err), c.Err, c.Attempt)
+		if ok != c.Retry {
+			t.Errorf("ShouldRetry(%v, %d) = %v, want %v", c.Err, c.Attempt, ok, c.Retry)
+		}
+		if ok && delay != policy.delay(c.Attempt) {
+			t.Errorf("ShouldRetry(%v, %d) delay = %v", c.Err, c.Attempt, delay)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package ftpclient
+
+import (
+	"errors"
+	"strings"
+)
+
+// telnetIAC is the Telnet "interpret as command" byte, doubled when sent as data.
+const telnetIAC = "\xff"
+
+// encodeCommand encodes a command line as RFC 2640 specifies for pathnames: IAC is
+// doubled and a CR is followed by NUL. A line feed cannot be sent at all.
+func encodeCommand(line string) (string, error) {
+	if strings.Contains(line, "\n") {
+		return "", errors.New("command contains a line feed")
+	}
+	line = strings.ReplaceAll(line, telnetIAC, telnetIAC+telnetIAC)
+	return strings.ReplaceAll(line, "\r", "\r\x00"), nil
+}
+
+// decodePath undoes the CR NUL escaping of a pathname received from the server.
+func decodePath(name string) string {
+	return strings.ReplaceAll(name, "\r\x00", "\r")
+}
+
+// parse257Quoted extracts the pathname of a 257 reply, where embedded quotes are
+// doubled as RFC 959 specifies.
+func parse257Quoted(msg string) (string, error) {
+	start := strings.Index(msg, "\"")
+	if start == -1 {
+		return "", errors.New("Unsuported response format")
+	}
+
+	var b strings.Builder
+	rest := msg[start+1:]
+	for {
+		i := strings.Index(rest, "\"")
+		if i == -1 {
+			return "", errors.New("Unsuported response format")
+		}
+		b.WriteString(rest[:i])
+		if !strings.HasPrefix(rest[i+1:], "\"") {
+			return decodePath(b.String()), nil
+		}
+		b.WriteString("\"")
+		rest = rest[i+2:]
+	}
+}
+
+// parsePathReply extracts the pathname of a 257 reply.
+func (c *FtpServerConn) parsePathReply(msg string) (string, error) {
+	if c.rfc2640 {
+		return parse257Quoted(msg)
+	}
+	path, err := parse257(msg)
+	if err != nil {
+		return "", err
+	}
+	if _, known := c.Charset(); known {
+		// readResponse decoded the reply already
+		return path, nil
+	}
+	return c.decodeText(path), nil
+}
+
+// listingLine decodes a line of listing data, as RFC 2640 specifies when enabled and
+// from the charset of the session otherwise.
+func (c *FtpServerConn) listingLine(line string) string {
+	if c.rfc2640 {
+		return decodePath(line)
+	}
+	return c.decodeText(line)
+}
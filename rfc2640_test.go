@@ -0,0 +1,22 @@
+package ftpclient
+
+import "testing"
+
+func TestParse257Quoted(t *testing.T) {
+	// go test -v -run TestParse257Quoted
+	cases := []struct {
+		Msg  string
+		Path string
+	}{
+		{`"/usr/dm" created.`, "/usr/dm"},
+		{`"/usr/dm/""quoted""" is current directory.`, `/usr/dm/"quoted"`},
+		{"\"/a\r\x00b\" created.", "/a\rb"},
+	}
+
+	for _, c := range cases {
+		path, err := parse257Quoted(c.Msg)
+		if err != nil || path != c.Path {
+			t.Errorf("parse257Quoted(%q) = %q, %v", c.Msg, path, err)
+		}
+	}
+}
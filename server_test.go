@@ -0,0 +1,284 @@
+package ftpclient
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testSession is the control connection of a client to a testServer.
+type testSession struct {
+	w    *bufio.Writer
+	data net.Listener
+}
+
+// reply writes a reply line to the client.
+func (s *testSession) reply(format string, args ...interface{}) {
+	fmt.Fprintf(s.w, format+"\r\n", args...)
+	s.w.Flush()
+}
+
+// accept returns the data connection opened by the client after PASV.
+func (s *testSession) accept() (net.Conn, error) {
+	defer s.data.Close()
+	return s.data.Accept()
+}
+
+// testServer serves scripted FTP sessions on a local address and returns it. USER is
+// answered by the server; other commands are passed to handle, which returns false
+// when it did not answer, in which case "200 ok" is sent, or a passive listener is
+// opened for PASV.
+func testServer(t *testing.T, handle func(s *testSession, cmd string) bool) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSession(conn, handle)
+		}
+	}()
+	return l.Addr().String()
+}
+
+func serveTestSession(conn net.Conn, handle func(s *testSession, cmd string) bool) {
+	defer conn.Close()
+	s := &testSession{w: bufio.NewWriter(conn)}
+	defer func() {
+		if s.data != nil {
+			s.data.Close()
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	s.reply("220 ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(cmd, "USER "):
+			s.reply("230 logged in")
+		case cmd == "PASV":
+			if handle(s, cmd) {
+				break
+			}
+			if s.data, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+				return
+			}
+			port := s.data.Addr().(*net.TCPAddr).Port
+			s.reply("227 Entering Passive Mode (127,0,0,1,%d,%d).", port/256, port%256)
+		case cmd == "QUIT":
+			s.reply("221 bye")
+			return
+		default:
+			if !handle(s, cmd) {
+				s.reply("200 ok")
+			}
+		}
+	}
+}
+
+// dialTestServer returns a client logged in to addr.
+func dialTestServer(t *testing.T, addr string, config *Config) *FtpServerConn {
+	c := New(config.WithPassive(true))
+	if err := c.Dial(addr); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Quit() })
+	if err := c.Login("user", "pass"); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// memFS is the in-memory file system of a memServer.
+type memFS struct {
+	mu    sync.Mutex
+	files map[string]string
+	cmds  []string
+}
+
+// file returns the content of name and whether it exists.
+func (fs *memFS) file(name string) (string, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	data, ok := fs.files[name]
+	return data, ok
+}
+
+// remove removes name and reports whether it existed. The builtin delete is shadowed
+// by a helper of ftpclient_test.go.
+func (fs *memFS) remove(name string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, ok := fs.files[name]
+	files := make(map[string]string, len(fs.files))
+	for n, data := range fs.files {
+		if n != name {
+			files[n] = data
+		}
+	}
+	fs.files = files
+	return ok
+}
+
+// list returns the MLSD facts of the entries of dir, where directories are the
+// prefixes of file names up to a slash, and whether dir exists.
+func (fs *memFS) list(dir string) ([]string, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	prefix := strings.Trim(dir, "/") + "/"
+	if prefix == "/" || prefix == "./" {
+		prefix = ""
+	}
+	seen := make(map[string]bool)
+	var facts []string
+	for name, data := range fs.files {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		rel := strings.TrimPrefix(name, prefix)
+		if sub, _, ok := strings.Cut(rel, "/"); ok {
+			if !seen[sub] {
+				seen[sub] = true
+				facts = append(facts, "type=dir; "+sub)
+			}
+		} else {
+			facts = append(facts, fmt.Sprintf("type=file;size=%d; %s", len(data), rel))
+		}
+	}
+	return facts, prefix == "" || len(facts) > 0
+}
+
+// commands returns the commands received with prefix.
+func (fs *memFS) commands(prefix string) []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var cmds []string
+	for _, cmd := range fs.cmds {
+		if strings.HasPrefix(cmd, prefix) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+// memServer serves files kept in memory with STOR, RETR, SIZE, MLST, MLSD, DELE, RNFR
+// and RNTO. Names are paths relative to the root directory, the only one answering
+// PWD and CWD.
+// Commands are passed to handle first when it is not nil.
+func memServer(t *testing.T, files map[string]string, handle func(s *testSession, cmd string) bool) (string, *memFS) {
+	fs := &memFS{files: files}
+	if fs.files == nil {
+		fs.files = make(map[string]string)
+	}
+
+	var from string
+	addr := testServer(t, func(s *testSession, cmd string) bool {
+		fs.mu.Lock()
+		fs.cmds = append(fs.cmds, cmd)
+		fs.mu.Unlock()
+		if handle != nil && handle(s, cmd) {
+			return true
+		}
+
+		verb, arg, _ := strings.Cut(cmd, " ")
+		switch verb {
+		case "STOR":
+			s.reply("150 opening")
+			conn, err := s.accept()
+			if err != nil {
+				return true
+			}
+			data, _ := io.ReadAll(conn)
+			conn.Close()
+			fs.mu.Lock()
+			fs.files[arg] = string(data)
+			fs.mu.Unlock()
+			s.reply("226 stored")
+		case "RETR":
+			data, ok := fs.file(arg)
+			if !ok {
+				s.reply("550 not found")
+				return true
+			}
+			s.reply("150 opening")
+			conn, err := s.accept()
+			if err != nil {
+				return true
+			}
+			io.WriteString(conn, data)
+			conn.Close()
+			s.reply("226 sent")
+		case "SIZE":
+			if data, ok := fs.file(arg); ok {
+				s.reply("213 %d", len(data))
+			} else {
+				s.reply("550 not found")
+			}
+		case "MLST":
+			if data, ok := fs.file(arg); ok {
+				s.reply("250-Listing %s\r\n type=file;size=%d; %s\r\n250 End", arg, len(data), arg)
+			} else {
+				s.reply("550 not found")
+			}
+		case "MLSD":
+			facts, ok := fs.list(arg)
+			if !ok {
+				s.reply("550 not found")
+				return true
+			}
+			s.reply("150 opening")
+			conn, err := s.accept()
+			if err != nil {
+				return true
+			}
+			for _, fact := range facts {
+				fmt.Fprintf(conn, "%s\r\n", fact)
+			}
+			conn.Close()
+			s.reply("226 sent")
+		case "PWD":
+			s.reply(`257 "/" is the current directory`)
+		case "CWD":
+			s.reply("250 directory changed")
+		case "DELE":
+			if fs.remove(arg) {
+				s.reply("250 deleted")
+			} else {
+				s.reply("550 not found")
+			}
+		case "RNFR":
+			if _, ok := fs.file(arg); ok {
+				from = arg
+				s.reply("350 ready")
+			} else {
+				s.reply("550 not found")
+			}
+		case "RNTO":
+			data, _ := fs.file(from)
+			fs.remove(from)
+			fs.mu.Lock()
+			fs.files[arg] = data
+			fs.mu.Unlock()
+			s.reply("250 renamed")
+		default:
+			return false
+		}
+		return true
+	})
+	return addr, fs
+}
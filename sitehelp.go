@@ -0,0 +1,111 @@
+package ftpclient
+
+import (
+	"net/textproto"
+	"strings"
+)
+
+// UnsupportedError is returned when the server is known not to implement a command.
+type UnsupportedError struct {
+	Command string
+}
+
+func (e *UnsupportedError) Error() string {
+	return e.Command + ": unsupported by server"
+}
+
+// SiteHelp issues a SITE HELP FTP command and returns the set of SITE commands the
+// server implements, e.g. CHMOD, UTIME, SYMLINK. The result is cached on the session.
+func (c *FtpServerConn) SiteHelp() (map[string]bool, error) {
+	if c.siteCommands != nil {
+		return c.siteCommands, nil
+	}
+
+	code, msg, err := c.SendCmd(-1, "SITE HELP")
+	if err != nil {
+		return nil, err
+	}
+	if code < 200 || code > 299 {
+		return nil, &textproto.Error{Code: code, Msg: msg}
+	}
+
+	c.siteCommands = parseSiteHelp(msg)
+	return c.siteCommands, nil
+}
+
+// Site issues a SITE FTP command with args, such as Site("UMASK", "022"), and returns
+// the code and lines of the reply as SendCmdLines does. Any positive completion reply
+// is accepted; other replies are returned as a *textproto.Error.
+func (c *FtpServerConn) Site(args ...string) (int, []string, error) {
+	return c.SendCmdLines(2, "SITE %s", strings.Join(args, " "))
+}
+
+// HasSiteCommand reports whether the server lists the SITE command name, e.g. CHMOD,
+// as a SITE feature in its FEAT reply or in its reply to SITE HELP.
+func (c *FtpServerConn) HasSiteCommand(name string) bool {
+	name = strings.ToUpper(name)
+	if c.featSite(name) {
+		return true
+	}
+	commands, err := c.SiteHelp()
+	return err == nil && commands[name]
+}
+
+// featSite reports whether FEAT lists "SITE name".
+func (c *FtpServerConn) featSite(name string) bool {
+	params, _ := c.FeatureParams("SITE")
+	for _, param := range strings.FieldsFunc(params, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+		if strings.EqualFold(param, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// requireSite returns an *UnsupportedError when SITE HELP shows that the server does
+// not implement the SITE command name. It returns nil when support is unknown.
+func (c *FtpServerConn) requireSite(name string) error {
+	if c.featSite(strings.ToUpper(name)) {
+		return nil
+	}
+	commands, err := c.SiteHelp()
+	if err != nil || len(commands) == 0 {
+		return nil
+	}
+	if !commands[strings.ToUpper(name)] {
+		return &UnsupportedError{Command: "SITE " + strings.ToUpper(name)}
+	}
+	return nil
+}
+
+// parseSiteHelp extracts command names from a SITE HELP reply. The first and last
+// lines are free text; names marked with '*' are unimplemented.
+func parseSiteHelp(msg string) map[string]bool {
+	commands := make(map[string]bool)
+	lines := strings.Split(msg, "\n")
+	if len(lines) < 3 {
+		// single line replies such as "214 CHMOD UMASK HELP"
+		lines = append([]string{""}, lines...)
+		lines = append(lines, "")
+	}
+
+	for _, line := range lines[1 : len(lines)-1] {
+		for _, field := range strings.Fields(line) {
+			if strings.HasSuffix(field, "*") || !isCommandName(field) {
+				continue
+			}
+			commands[strings.ToUpper(field)] = true
+		}
+	}
+	return commands
+}
+
+// isCommandName reports whether s looks like a FTP command name.
+func isCommandName(s string) bool {
+	for _, r := range s {
+		if !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z') {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,47 @@
+package ftpclient
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// NewSlogLogger returns a LeveledLogger writing to l. Messages logged without a
+// level, through Log and Logf, are written at LevelInfo.
+func NewSlogLogger(l *slog.Logger) LeveledLogger {
+	return &slogLogger{logger: l}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+func (l *slogLogger) Log(args ...interface{}) {
+	l.logger.Info(fmt.Sprint(args...))
+}
+
+func (l *slogLogger) Logf(format string, args ...interface{}) {
+	l.logger.Info(fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) LogLevel(level Level, format string, args ...interface{}) {
+	lvl := slogLevel(level)
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, lvl) {
+		return
+	}
+	l.logger.Log(ctx, lvl, fmt.Sprintf(format, args...))
+}
+
+// slogLevel maps a Level to the slog level of the same name.
+func slogLevel(level Level) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelInfo:
+		return slog.LevelInfo
+	case LevelWarn:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
@@ -0,0 +1,83 @@
+package ftpclient
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// smallFileBuffers holds the buffers used for small file transfers.
+var smallFileBuffers = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getSmallFileBuffer() *bytes.Buffer {
+	buf := smallFileBuffers.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+func putSmallFileBuffer(buf *bytes.Buffer, threshold int64) {
+	// do not keep buffers grown past the threshold alive
+	if int64(buf.Cap()) <= 2*threshold {
+		smallFileBuffers.Put(buf)
+	}
+}
+
+// isSmallFile reports whether local is small enough to be uploaded from memory.
+func (c *FtpServerConn) isSmallFile(local string) bool {
+	if c.smallFileThreshold <= 0 {
+		return false
+	}
+	info, err := os.Stat(local)
+	return err == nil && info.Size() <= c.smallFileThreshold
+}
+
+// storSmallFile reads local into a pooled buffer and stores it with a single write
+// on the data connection.
+func (c *FtpServerConn) storSmallFile(local, remote string) (err error) {
+	buf := getSmallFileBuffer()
+	defer putSmallFileBuffer(buf, c.smallFileThreshold)
+
+	file, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	_, err = buf.ReadFrom(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	writer, err := c.StorRequest(remote)
+	if err != nil {
+		return err
+	}
+	setTotal(writer, int64(buf.Len()))
+	defer func() {
+		if cerr := writer.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	_, err = writer.Write(buf.Bytes())
+	return err
+}
+
+// copySmall reads up to the small file threshold from r into a pooled buffer and
+// writes it to w at once. done reports whether r was exhausted; otherwise the caller
+// streams the rest.
+func (c *FtpServerConn) copySmall(w io.Writer, r io.Reader) (n int64, done bool, err error) {
+	buf := getSmallFileBuffer()
+	defer putSmallFileBuffer(buf, c.smallFileThreshold)
+
+	m, err := buf.ReadFrom(io.LimitReader(r, c.smallFileThreshold+1))
+	if err != nil {
+		return 0, false, err
+	}
+	nw, err := w.Write(buf.Bytes())
+	return int64(nw), m <= c.smallFileThreshold, err
+}
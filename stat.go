@@ -0,0 +1,159 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/tsujimic/ftpclient-go/replycode"
+)
+
+// Stat returns the FileInfo of the single file or directory name. It uses MLST when
+// the server implements it, then SIZE and MDTM, which only describe files, and
+// finally looks name up in the listing of its parent directory. A missing file is
+// reported as an *os.PathError wrapping os.ErrNotExist.
+func (c *FtpServerConn) Stat(name string) (os.FileInfo, error) {
+	base := path.Base(name)
+	if !c.mlstUnsupported {
+		e, err := c.Mlst(name)
+		switch {
+		case err == nil:
+			info := e.FileInfo().(*fileInfo)
+			info.name = base
+			return info, nil
+		case isNotImplemented(err):
+			c.mlstUnsupported = true
+		case isReply(err, 550):
+			return nil, replyPathError("stat", name, err)
+		default:
+			return nil, err
+		}
+	}
+
+	if size, err := c.Size(name); err == nil {
+		mtime, _ := c.ModTime(name)
+		return &fileInfo{name: base, size: int64(size), mtime: mtime}, nil
+	}
+
+	infos, err := c.readDir(path.Dir(name))
+	if isReply(err, 550) {
+		return nil, replyPathError("stat", name, err)
+	}
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	for _, info := range infos {
+		if info.Name() == base {
+			return info, nil
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+// StatContext is like Stat but honors ctx.
+func (c *FtpServerConn) StatContext(ctx context.Context, name string) (info os.FileInfo, err error) {
+	err = c.withContext(ctx, func() error {
+		info, err = c.Stat(name)
+		return err
+	})
+	return info, err
+}
+
+// Exists reports whether the file or directory name exists, probing it as Stat does.
+// A 550 reply naming a permission problem is returned as an *os.PathError wrapping
+// os.ErrPermission rather than reported as a missing file.
+func (c *FtpServerConn) Exists(name string) (bool, error) {
+	_, err := c.Stat(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// IsDir reports whether name is a directory. It uses MLST when the server implements
+// it and otherwise tries to enter name with CWD, returning to the current directory.
+// Names that cannot be entered are looked up with Stat, so that a missing name is
+// reported as an *os.PathError wrapping os.ErrNotExist.
+func (c *FtpServerConn) IsDir(name string) (bool, error) {
+	if !c.mlstUnsupported {
+		e, err := c.Mlst(name)
+		switch {
+		case err == nil:
+			return e.Type == "dir" || e.Type == "cdir" || e.Type == "pdir", nil
+		case isNotImplemented(err):
+			c.mlstUnsupported = true
+		case isReply(err, 550):
+			return false, replyPathError("stat", name, err)
+		default:
+			return false, err
+		}
+	}
+
+	_, err := c.realDir(name)
+	if err == nil {
+		return true, nil
+	}
+	if !isPermanentNegative(err) {
+		return false, err
+	}
+	info, err := c.Stat(name)
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+// ExistsContext is like Exists but honors ctx.
+func (c *FtpServerConn) ExistsContext(ctx context.Context, name string) (exists bool, err error) {
+	err = c.withContext(ctx, func() error {
+		exists, err = c.Exists(name)
+		return err
+	})
+	return exists, err
+}
+
+// IsDirContext is like IsDir but honors ctx.
+func (c *FtpServerConn) IsDirContext(ctx context.Context, name string) (dir bool, err error) {
+	err = c.withContext(ctx, func() error {
+		dir, err = c.IsDir(name)
+		return err
+	})
+	return dir, err
+}
+
+// permissionWords are found in the text of 550 replies refusing access, as opposed to
+// those reporting a missing file.
+var permissionWords = []string{"permission", "denied", "not permitted", "forbidden", "not allowed"}
+
+// replyPathError converts a 550 reply to an *os.PathError wrapping os.ErrPermission
+// when its text names a permission problem and os.ErrNotExist otherwise. Other
+// errors are returned unchanged.
+func replyPathError(op, name string, err error) error {
+	var perr *textproto.Error
+	if !errors.As(err, &perr) || perr.Code != 550 {
+		return err
+	}
+	msg := strings.ToLower(perr.Msg)
+	for _, word := range permissionWords {
+		if strings.Contains(msg, word) {
+			return &os.PathError{Op: op, Path: name, Err: fmt.Errorf("%w: %s", os.ErrPermission, perr.Msg)}
+		}
+	}
+	return &os.PathError{Op: op, Path: name, Err: fmt.Errorf("%w: %s", os.ErrNotExist, perr.Msg)}
+}
+
+// isPermanentNegative reports whether err is a 5xx reply of the server.
+func isPermanentNegative(err error) bool {
+	var perr *textproto.Error
+	return errors.As(err, &perr) && replycode.IsPermanentNegative(perr.Code)
+}
+
+// isReply reports whether err is a reply of the server with code.
+func isReply(err error, code int) bool {
+	var perr *textproto.Error
+	return errors.As(err, &perr) && perr.Code == code
+}
@@ -0,0 +1,31 @@
+package ftpclient
+
+import (
+	"errors"
+	"net/textproto"
+	"os"
+	"testing"
+)
+
+func TestReplyPathError(t *testing.T) {
+	// go test -v -run TestReplyPathError
+	cases := []struct {
+		Err  error
+		Want error
+	}{
+		{&textproto.Error{Code: 550, Msg: "a.txt: No such file or directory"}, os.ErrNotExist},
+		{&textproto.Error{Code: 550, Msg: "a.txt: Permission denied"}, os.ErrPermission},
+		{&textproto.Error{Code: 550, Msg: "Access is denied."}, os.ErrPermission},
+	}
+
+	for _, c := range cases {
+		if err := replyPathError("stat", "a.txt", c.Err); !errors.Is(err, c.Want) {
+			t.Errorf("replyPathError(%v) = %v, want %v", c.Err, err, c.Want)
+		}
+	}
+
+	err := &textproto.Error{Code: 450, Msg: "busy"}
+	if got := replyPathError("stat", "a.txt", err); got != error(err) {
+		t.Errorf("replyPathError(%v) = %v", err, got)
+	}
+}
@@ -0,0 +1,70 @@
+package ftpclient
+
+import (
+	"time"
+)
+
+// TransferStats describes a completed file transfer. Bytes counts the data moved
+// over all attempts, Duration covers the whole operation including commands and
+// retries, and TimeToFirstByte is the time until the first data byte moved.
+// Throughput is the average rate in bytes per second.
+type TransferStats struct {
+	Bytes           int64
+	Duration        time.Duration
+	TimeToFirstByte time.Duration
+	Throughput      float64
+}
+
+// transferRecorder accumulates TransferStats over the data connections of one operation.
+type transferRecorder struct {
+	start     time.Time
+	firstByte time.Duration
+	bytes     int64
+}
+
+// record runs fn while recording the data connections it opens.
+func (c *FtpServerConn) record(fn func() error) (TransferStats, error) {
+	rec := &transferRecorder{start: time.Now()}
+	c.recorder = rec
+	err := fn()
+	c.recorder = nil
+
+	stats := TransferStats{
+		Bytes:           rec.bytes,
+		Duration:        time.Since(rec.start),
+		TimeToFirstByte: rec.firstByte,
+	}
+	if sec := stats.Duration.Seconds(); sec > 0 {
+		stats.Throughput = float64(stats.Bytes) / sec
+	}
+	return stats, err
+}
+
+// recordBytes notes that n bytes moved over d.
+func (d *FtpDataConn) recordBytes(n int) {
+	if t := d.c.currentTransferLog(); t != nil && !d.listing {
+		t.bytes += int64(n)
+	}
+	rec := d.c.recorder
+	if rec == nil || d.listing || n <= 0 {
+		return
+	}
+	if rec.firstByte == 0 {
+		rec.firstByte = time.Since(rec.start)
+	}
+	rec.bytes += int64(n)
+}
+
+// RetrFileStats is like RetrFile but also returns the statistics of the transfer.
+func (c *FtpServerConn) RetrFileStats(remote, local string) (TransferStats, error) {
+	return c.record(func() error {
+		return c.RetrFile(remote, local)
+	})
+}
+
+// StorFileStats is like StorFile but also returns the statistics of the transfer.
+func (c *FtpServerConn) StorFileStats(local, remote string) (TransferStats, error) {
+	return c.record(func() error {
+		return c.StorFile(local, remote)
+	})
+}
@@ -0,0 +1,62 @@
+package ftpclient
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StouWriter is the WriteCloser returned by StouRequest.
+type StouWriter struct {
+	*FtpDataConn
+	name string
+}
+
+// Name returns the file name assigned by the server. When the preliminary reply did
+// not contain it, it is taken from the final reply once the writer is closed.
+func (w *StouWriter) Name() string {
+	return w.name
+}
+
+// Close implements the io.Closer interface on a FTP data connection.
+func (w *StouWriter) Close() error {
+	err := w.FtpDataConn.Close()
+	if w.name == "" {
+		w.name = parseStouName(w.reply)
+	}
+	return err
+}
+
+// StouRequest issues a STOU FTP command to store a file under a name chosen by the
+// remote FTP server, and returns the server-assigned name when the reply carries it.
+// The returned StouWriter must be closed to cleanup the FTP data connection.
+func (c *FtpServerConn) StouRequest() (*StouWriter, string, error) {
+	conn, err := c.transferCmd("STOU")
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := parseStouName(c.lastMsg)
+	w := &StouWriter{
+		FtpDataConn: c.newDataConn(conn),
+		name:        name,
+	}
+	return w, name, nil
+}
+
+var regexpStouQuoted = regexp.MustCompile("[\"']([^\"']+)[\"']")
+
+// parseStouName extracts the file name from a STOU reply, such as
+// "FILE: name" (RFC 1123), "Opening BINARY mode data connection for 'name'"
+// or "Transfer complete (unique file name:name)".
+func parseStouName(msg string) string {
+	if i := strings.Index(msg, "FILE:"); i != -1 {
+		return strings.TrimSpace(msg[i+len("FILE:"):])
+	}
+	if i := strings.Index(strings.ToLower(msg), "unique file name:"); i != -1 {
+		return strings.TrimRight(strings.TrimSpace(msg[i+len("unique file name:"):]), ").")
+	}
+	if matches := regexpStouQuoted.FindStringSubmatch(msg); matches != nil {
+		return matches[1]
+	}
+	return ""
+}
@@ -0,0 +1,21 @@
+package ftpclient
+
+import (
+	"errors"
+)
+
+// secondary handles errors that happen besides the outcome of an operation, such as
+// closing a connection after a failed command. They are joined into *err when strict
+// errors are enabled, and only logged otherwise.
+func (c *FtpServerConn) secondary(err *error, errs ...error) {
+	for _, e := range errs {
+		if e == nil {
+			continue
+		}
+		if c.strictErrors {
+			*err = errors.Join(*err, e)
+		} else {
+			c.logf("ignored error: %v", e)
+		}
+	}
+}
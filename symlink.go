@@ -0,0 +1,65 @@
+package ftpclient
+
+import (
+	"context"
+	"os"
+	"path"
+)
+
+// ResolveSymlink reports what the symbolic link name points at. The link is entered
+// with CWD: links to directories are returned with os.ModeDir, other links as
+// regular files, detailed with MLST when the server supports it. The returned
+// FileInfo is named after the link. Dangling links are reported as files, since
+// FTP cannot tell them apart from links to files the user may not enter.
+func (c *FtpServerConn) ResolveSymlink(name string) (os.FileInfo, error) {
+	info, _, err := c.resolveSymlink(name)
+	return info, err
+}
+
+// ResolveSymlinkContext is like ResolveSymlink but honors ctx.
+func (c *FtpServerConn) ResolveSymlinkContext(ctx context.Context, name string) (info os.FileInfo, err error) {
+	err = c.withContext(ctx, func() error {
+		info, err = c.ResolveSymlink(name)
+		return err
+	})
+	return info, err
+}
+
+// resolveSymlink is ResolveSymlink also returning the real path of directories.
+func (c *FtpServerConn) resolveSymlink(name string) (os.FileInfo, string, error) {
+	base := path.Base(name)
+	real, err := c.realDir(name)
+	if err == nil {
+		return &fileInfo{name: base, mode: os.ModeDir}, real, nil
+	}
+	if !isPermanentNegative(err) {
+		return nil, "", err
+	}
+
+	info := &fileInfo{name: base}
+	if c.HasFeature("MLST") {
+		if e, err := c.Mlst(name); err == nil && e.Type == "file" {
+			info = e.FileInfo().(*fileInfo)
+			info.name = base
+		}
+	}
+	return info, "", nil
+}
+
+// realDir returns the path the server reports for dir once entered, and returns to
+// the current directory.
+func (c *FtpServerConn) realDir(dir string) (string, error) {
+	cwd, err := c.Pwd()
+	if err != nil {
+		return "", err
+	}
+	if err := c.Cwd(dir); err != nil {
+		return "", err
+	}
+
+	real, err := c.Pwd()
+	if cerr := c.Cwd(cwd); cerr != nil {
+		return "", cerr
+	}
+	return real, err
+}
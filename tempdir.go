@@ -0,0 +1,43 @@
+package ftpclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+)
+
+// sessionTempDir returns the temporary directory of the session below the configured
+// temp directory, creating both on first use. Each session uses its own directory,
+// so that concurrent sessions never clean up each other's temporaries.
+func (c *FtpServerConn) sessionTempDir() (string, error) {
+	if c.tempDirPath != "" {
+		return c.tempDirPath, nil
+	}
+
+	b := make([]byte, 8)
+	rand.Read(b)
+	dir := path.Join(c.tempDir, "session-"+hex.EncodeToString(b))
+	if err := c.mkdir(c.tempDir); err != nil {
+		return "", err
+	}
+	if err := c.mkdir(dir); err != nil {
+		return "", err
+	}
+	c.tempDirPath = dir
+	return dir, nil
+}
+
+// CleanupTempDir removes the temporary directory of the session and everything left
+// in it, such as the temporaries of an interrupted transaction. It is called by Quit,
+// and may be called when a job completes. It does nothing when no temp directory is
+// configured or none was used.
+func (c *FtpServerConn) CleanupTempDir() error {
+	if c.tempDirPath == "" {
+		return nil
+	}
+	if err := c.removeAll(c.tempDirPath); err != nil {
+		return err
+	}
+	c.tempDirPath = ""
+	return nil
+}
@@ -0,0 +1,103 @@
+package ftpclient
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path"
+	"strconv"
+)
+
+// TempNamer returns the temporary name under which remote is uploaded before it
+// is renamed to its final name.
+type TempNamer func(remote string) string
+
+// SuffixTempNamer appends suffix to the remote name, e.g. "data.csv.part".
+func SuffixTempNamer(suffix string) TempNamer {
+	return func(remote string) string {
+		return remote + suffix
+	}
+}
+
+// PrefixTempNamer prepends prefix to the base name, e.g. "dir/.data.csv".
+func PrefixTempNamer(prefix string) TempNamer {
+	return func(remote string) string {
+		dir, base := path.Split(remote)
+		return dir + prefix + base
+	}
+}
+
+// DirTempNamer places the name produced by namer in the staging directory dir.
+func DirTempNamer(dir string, namer TempNamer) TempNamer {
+	return func(remote string) string {
+		return path.Join(dir, path.Base(namer(remote)))
+	}
+}
+
+// RandomTempNamer appends a random token and suffix to the remote name,
+// e.g. "data.csv.3f9a1c0b2d4e5f60.tmp".
+func RandomTempNamer(suffix string) TempNamer {
+	return func(remote string) string {
+		b := make([]byte, 8)
+		rand.Read(b)
+		return remote + "." + hex.EncodeToString(b) + suffix
+	}
+}
+
+// PIDTempNamer appends the process ID and suffix to the remote name,
+// e.g. "data.csv.4242.tmp".
+func PIDTempNamer(suffix string) TempNamer {
+	pid := strconv.Itoa(os.Getpid())
+	return func(remote string) string {
+		return remote + "." + pid + suffix
+	}
+}
+
+// DefaultTempNamer is used when no TempNamer is configured.
+var DefaultTempNamer = SuffixTempNamer(".tmp")
+
+// ErrTempNameExists is returned when no unused temporary name could be generated.
+var ErrTempNameExists = errors.New("temporary name already exists")
+
+const tempNameAttempts = 10
+
+// TempName returns a temporary name for remote generated by the configured TempNamer
+// that does not exist on the server yet, neither as a file nor as a directory, probed
+// as Stat does. When a deterministic namer repeats a name that exists, such as one
+// left behind by a failed upload, a numbered suffix is appended to it, e.g.
+// "data.csv.tmp.1". When a temp directory is configured, the name is placed in the
+// temporary directory of the session, created on demand.
+func (c *FtpServerConn) TempName(remote string) (string, error) {
+	namer := c.tempNamer
+	if namer == nil {
+		namer = DefaultTempNamer
+	}
+	if c.tempDir != "" {
+		dir, err := c.sessionTempDir()
+		if err != nil {
+			return "", err
+		}
+		namer = DirTempNamer(dir, namer)
+	}
+
+	var first string
+	for i := 0; i < tempNameAttempts; i++ {
+		name := namer(remote)
+		if i == 0 {
+			first = name
+		} else if name == first {
+			name += "." + strconv.Itoa(i)
+		}
+
+		// directories take a name as well as files
+		exists, err := c.Exists(name)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return name, nil
+		}
+	}
+	return "", ErrTempNameExists
+}
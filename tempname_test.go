@@ -0,0 +1,123 @@
+package ftpclient
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTempNamers(t *testing.T) {
+	cases := []struct {
+		Namer  TempNamer
+		Remote string
+		Want   string
+	}{
+		{SuffixTempNamer(".part"), "dir/data.csv", "dir/data.csv.part"},
+		{PrefixTempNamer("."), "dir/data.csv", "dir/.data.csv"},
+		{PrefixTempNamer("."), "data.csv", ".data.csv"},
+		{DirTempNamer("/staging", SuffixTempNamer(".tmp")), "dir/data.csv", "/staging/data.csv.tmp"},
+		{DefaultTempNamer, "data.csv", "data.csv.tmp"},
+	}
+
+	for _, tc := range cases {
+		if got := tc.Namer(tc.Remote); got != tc.Want {
+			t.Errorf("namer(%q) = %q, want %q", tc.Remote, got, tc.Want)
+		}
+	}
+
+	random := RandomTempNamer(".tmp")
+	a, b := random("data.csv"), random("data.csv")
+	if a == b || !strings.HasPrefix(a, "data.csv.") || !strings.HasSuffix(a, ".tmp") {
+		t.Errorf("random names %q and %q", a, b)
+	}
+}
+
+func TestTempName(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Existing map[string]bool // names on the server, true for directories
+		Size     string          // reply to SIZE of a missing file
+		Mlst     string          // reply to MLST, not implemented when empty
+		Want     string
+		Err      error
+	}{
+		{"free", nil, "550 not found", "", "data.csv.tmp", nil},
+		{"leftover", map[string]bool{"data.csv.tmp": false}, "550 not found", "", "data.csv.tmp.1", nil},
+		{"leftovers", map[string]bool{"data.csv.tmp": false, "data.csv.tmp.1": false}, "550 not found", "", "data.csv.tmp.2", nil},
+		// SIZE fails with 550 for directories
+		{"directory", map[string]bool{"data.csv.tmp": true}, "550 not a plain file", "", "data.csv.tmp.1", nil},
+		{"no size command", map[string]bool{"data.csv.tmp": false}, "502 not implemented", "", "data.csv.tmp.1", nil},
+		{"failure", nil, "550 not found", "451 local error", "", &textproto.Error{Code: 451, Msg: "local error"}},
+		{"exhausted", tempNames("data.csv.tmp"), "550 not found", "", "", ErrTempNameExists},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addr := testServer(t, func(s *testSession, cmd string) bool {
+				verb, arg, _ := strings.Cut(cmd, " ")
+				switch verb {
+				case "MLST":
+					if tc.Mlst == "" {
+						s.reply("502 not implemented")
+					} else {
+						s.reply(tc.Mlst)
+					}
+				case "SIZE":
+					if dir, ok := tc.Existing[arg]; ok && !dir {
+						s.reply("213 10")
+					} else {
+						s.reply(tc.Size)
+					}
+				case "LIST":
+					s.reply("150 opening")
+					conn, err := s.accept()
+					if err != nil {
+						return true
+					}
+					for name, dir := range tc.Existing {
+						mode := "-rw-r--r--"
+						if dir {
+							mode = "drwxr-xr-x"
+						}
+						fmt.Fprintf(conn, "%s   1 owner group        10 Jan 02 15:04 %s\r\n", mode, name)
+					}
+					conn.Close()
+					s.reply("226 sent")
+				case "MDTM":
+					s.reply("213 20200102150400")
+				case "PASV":
+					return false
+				default:
+					s.reply("502 not implemented")
+				}
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig())
+
+			name, err := c.TempName("data.csv")
+			if name != tc.Want {
+				t.Errorf("name = %q, want %q", name, tc.Want)
+			}
+			var perr *textproto.Error
+			if errors.As(tc.Err, &perr) {
+				if !isReply(err, perr.Code) {
+					t.Errorf("err = %v, want %v", err, tc.Err)
+				}
+			} else if err != tc.Err {
+				t.Errorf("err = %v, want %v", err, tc.Err)
+			}
+		})
+	}
+}
+
+// tempNames returns every name TempName tries for a deterministic namer.
+func tempNames(name string) map[string]bool {
+	names := map[string]bool{name: false}
+	for i := 1; i < tempNameAttempts; i++ {
+		names[name+"."+strconv.Itoa(i)] = false
+	}
+	return names
+}
@@ -0,0 +1,13 @@
+package ftpclient
+
+import (
+	"time"
+)
+
+// timeout returns t, or the read/write timeout when t is not set.
+func (c *Config) timeout(t time.Duration) time.Duration {
+	if t > 0 {
+		return t
+	}
+	return c.readWriteTimeout
+}
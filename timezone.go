@@ -0,0 +1,83 @@
+package ftpclient
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"time"
+)
+
+// maxZoneOffset bounds the offsets accepted by DetectServerLocation.
+const maxZoneOffset = 14 * time.Hour
+
+// DetectServerLocation compares the LIST time of the file name with its MDTM time,
+// which RFC 3659 defines in UTC, and uses the difference as the timezone of LIST
+// timestamps for the rest of the session, in place of the configured
+// WithServerLocation. As LIST omits the time of day of older files, name should
+// be a file modified within the last six months.
+func (c *FtpServerConn) DetectServerLocation(name string) (*time.Location, error) {
+	mtime, err := c.ModTime(name)
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(mtime) > 180*24*time.Hour {
+		return nil, fmt.Errorf("detect server location: %s is too old to be listed with a time of day", name)
+	}
+
+	// list in the server's wall clock
+	detected := c.detectedLocation
+	c.detectedLocation = time.UTC
+	infos, err := c.dir(name)
+	c.detectedLocation = detected
+	if err != nil {
+		return nil, err
+	}
+
+	var listed *fileInfo
+	for _, info := range infos {
+		if f, ok := info.(*fileInfo); ok && f.local && f.name == path.Base(name) {
+			listed = f
+			break
+		}
+	}
+	if listed == nil {
+		return nil, fmt.Errorf("detect server location: %s not found in listing", name)
+	}
+
+	// LIST truncates to the minute and zones are offset by multiples of 15 minutes
+	offset := listed.mtime.Sub(mtime.Truncate(time.Minute)).Round(15 * time.Minute)
+	if offset < -maxZoneOffset || offset > maxZoneOffset {
+		return nil, errors.New("detect server location: listed and MDTM times differ by more than a timezone")
+	}
+
+	seconds := int(offset.Seconds())
+	loc := time.FixedZone("UTC"+time.Time{}.In(time.FixedZone("", seconds)).Format("-07:00"), seconds)
+	c.detectedLocation = loc
+	c.infof("detected server timezone: %s", loc)
+	return loc, nil
+}
+
+// listLocation returns the timezone of LIST timestamps, or nil when they are UTC.
+func (c *FtpServerConn) listLocation() *time.Location {
+	if c.detectedLocation != nil {
+		return c.detectedLocation
+	}
+	return c.serverLocation
+}
+
+// localize converts the wall clock time of a listed entry to UTC in the timezone of
+// the server. Entries of custom parsers are returned as they are.
+func (c *FtpServerConn) localize(info os.FileInfo) os.FileInfo {
+	f, ok := info.(*fileInfo)
+	loc := c.listLocation()
+	if !ok || !f.local || f.mtime.IsZero() || loc == nil || loc == time.UTC {
+		return info
+	}
+
+	t := f.mtime
+	localized := *f
+	localized.mtime = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc).UTC()
+	localized.local = false
+	return &localized
+}
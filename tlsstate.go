@@ -0,0 +1,87 @@
+package ftpclient
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"net"
+)
+
+// ErrTLSSessionBinding is returned when a passive data connection is not bound to
+// the TLS session of the control connection, which may indicate a MITM attempt.
+var ErrTLSSessionBinding = errors.New("data connection TLS session is not bound to the control connection")
+
+// newTLSSession returns the tls.Config shared by the control and data connections of
+// a session, so that data connections resume the TLS session of the control
+// connection, as servers like ProFTPD and FileZilla Server require. It always has a
+// session cache, kept across reconnects and shared with clones, and its server name,
+// which keys the cache, defaults to the host of addr.
+func (c *FtpServerConn) newTLSSession(addr string) *tls.Config {
+	config := c.tlsConfig.Clone()
+	if config.ClientSessionCache == nil && c.tlsSession != nil {
+		config.ClientSessionCache = c.tlsSession.ClientSessionCache
+	}
+	if config.ClientSessionCache == nil {
+		config.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+	}
+	if config.ServerName == "" {
+		config.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+	return config
+}
+
+// verifyTLSSessionBinding completes the handshake of a data connection and checks that
+// it resumed the control connection session with the same server certificate.
+func (c *FtpServerConn) verifyTLSSessionBinding(data *tls.Conn) error {
+	control, ok := c.conn.(*tls.Conn)
+	if !ok {
+		return ErrTLSSessionBinding
+	}
+
+	c.setReadDeadline(data, c.timeout(c.dataTimeout))
+	c.setWriteDeadline(data, c.timeout(c.dataTimeout))
+	if err := data.HandshakeContext(c.context()); err != nil {
+		return err
+	}
+
+	dataState := data.ConnectionState()
+	controlState := control.ConnectionState()
+	if !dataState.DidResume {
+		c.warnf("data connection did not resume the control connection TLS session")
+		return ErrTLSSessionBinding
+	}
+	if !samePeerCertificate(dataState, controlState) {
+		c.warnf("data connection presented a different server certificate")
+		return ErrTLSSessionBinding
+	}
+	return nil
+}
+
+// samePeerCertificate reports whether both connections present the same leaf certificate.
+func samePeerCertificate(a, b tls.ConnectionState) bool {
+	if len(a.PeerCertificates) == 0 || len(b.PeerCertificates) == 0 {
+		// resumed TLS 1.2 sessions do not repeat the certificate
+		return a.DidResume && len(b.PeerCertificates) > 0
+	}
+	return bytes.Equal(a.PeerCertificates[0].Raw, b.PeerCertificates[0].Raw)
+}
+
+// TLSConnectionState returns the negotiated TLS state of the control connection.
+// ok is false when the control connection is not protected by TLS.
+func (c *FtpServerConn) TLSConnectionState() (state tls.ConnectionState, ok bool) {
+	tlsConn, ok := c.conn.(*tls.Conn)
+	if !ok {
+		return state, false
+	}
+	return tlsConn.ConnectionState(), true
+}
+
+// TLSConnectionState returns the negotiated TLS state of the data connection.
+// ok is false when the data connection is not protected by TLS.
+func (d *FtpDataConn) TLSConnectionState() (state tls.ConnectionState, ok bool) {
+	tlsConn, ok := d.conn.(*tls.Conn)
+	if !ok {
+		return state, false
+	}
+	return tlsConn.ConnectionState(), true
+}
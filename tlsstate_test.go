@@ -0,0 +1,36 @@
+package ftpclient
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestNewTLSSession(t *testing.T) {
+	config := &tls.Config{}
+	c := New(NewConfig().WithTLSConfig(config))
+
+	session := c.newTLSSession("ftp.example.com:21")
+	if session.ClientSessionCache == nil {
+		t.Fatal("session has no cache")
+	}
+	if session.ServerName != "ftp.example.com" {
+		t.Errorf("server name = %q, want ftp.example.com", session.ServerName)
+	}
+	if config.ClientSessionCache != nil || config.ServerName != "" {
+		t.Error("config of the caller was modified")
+	}
+
+	// a clone starts from the TLS session of its parent
+	clone := New(c.Config)
+	clone.tlsSession = session
+	if got := clone.newTLSSession("ftp.example.com:21"); got.ClientSessionCache != session.ClientSessionCache {
+		t.Error("clone does not share the session cache")
+	}
+
+	cache := tls.NewLRUClientSessionCache(1)
+	c = New(NewConfig().WithTLSConfig(&tls.Config{ClientSessionCache: cache}))
+	c.tlsSession = session
+	if got := c.newTLSSession("ftp.example.com:21"); got.ClientSessionCache != cache {
+		t.Error("configured session cache is not used")
+	}
+}
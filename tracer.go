@@ -0,0 +1,52 @@
+package ftpclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Tracer observes the wire protocol of a FtpServerConn, separately from the Logger,
+// so that tools can build a transcript of a session. Commands are reported as sent,
+// with the password of PASS masked, and replies as received, including the replies
+// to keepalive NOOPs. Data connection traffic is reported as byte counts. Methods are
+// called synchronously from the goroutine doing the I/O.
+type Tracer interface {
+	OnCommandSent(cmd string)
+	OnReplyReceived(code int, msg string)
+	OnDataSent(n int)
+	OnDataReceived(n int)
+}
+
+// traceCommand reports a command line to the configured Tracer.
+func (c *FtpServerConn) traceCommand(format string, args ...interface{}) {
+	if c.tracer == nil {
+		return
+	}
+	if strings.HasPrefix(format, "PASS") {
+		c.tracer.OnCommandSent("PASS ***")
+		return
+	}
+	c.tracer.OnCommandSent(fmt.Sprintf(format, args...))
+}
+
+// readReply reads a reply from the control connection and reports it to the
+// configured Tracer.
+func (c *FtpServerConn) readReply(expectCode int) (int, string, error) {
+	code, msg, err := c.textprotoConn.ReadResponse(expectCode)
+	if c.tracer != nil && code != 0 {
+		c.tracer.OnReplyReceived(code, msg)
+	}
+	return code, msg, err
+}
+
+// traceData reports n bytes moved over d to the configured Tracer.
+func (d *FtpDataConn) traceData(n int, sent bool) {
+	if d.c.tracer == nil || n <= 0 {
+		return
+	}
+	if sent {
+		d.c.tracer.OnDataSent(n)
+	} else {
+		d.c.tracer.OnDataReceived(n)
+	}
+}
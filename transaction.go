@@ -0,0 +1,106 @@
+package ftpclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TransactionalUpload describes one file of a transactional multi-file publish.
+type TransactionalUpload struct {
+	Local  string
+	Remote string
+}
+
+// TransactionError is returned by WithTransactionalRename when the publish failed.
+// RollbackErrs holds any error raised while cleaning up temporary files.
+type TransactionError struct {
+	Remote       string
+	Err          error
+	RollbackErrs []error
+}
+
+func (e *TransactionError) Error() string {
+	msg := fmt.Sprintf("transaction failed at %q: %v", e.Remote, e.Err)
+	if len(e.RollbackErrs) > 0 {
+		errs := make([]string, 0, len(e.RollbackErrs))
+		for _, err := range e.RollbackErrs {
+			errs = append(errs, err.Error())
+		}
+		msg += " (rollback: " + strings.Join(errs, "; ") + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the error that caused the transaction to fail.
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// WithTransactionalRename uploads every file to a temporary name and renames them
+// all to their final names once every upload succeeded and its remote size matches
+// the local file. On failure, including a failure to write a marker file, the
+// temporary and marker files are deleted and files that were already renamed are
+// moved back and deleted, giving approximate atomicity for multi-file publishes.
+func (c *FtpServerConn) WithTransactionalRename(uploads []TransactionalUpload) error {
+	remotes := make([]string, len(uploads))
+	temps := make([]string, 0, len(uploads))
+	for i, u := range uploads {
+		remotes[i] = c.mapName(u.Remote)
+		temp, err := c.TempName(remotes[i])
+		if err != nil {
+			return c.rollbackTransaction(remotes[i], err, temps, nil)
+		}
+		if err = c.storFileRetry(u.Local, temp); err != nil {
+			// the failed upload may have left a partial file behind
+			return c.rollbackTransaction(remotes[i], err, append(temps, temp), nil)
+		}
+		temps = append(temps, temp)
+		if err = c.verifyUpload(u.Local, temp); err != nil {
+			return c.rollbackTransaction(remotes[i], err, temps, nil)
+		}
+	}
+
+	renamed := make([]TransactionalUpload, 0, len(uploads))
+	for i, remote := range remotes {
+		if err := c.Rename(temps[i], remote); err != nil {
+			return c.rollbackTransaction(remote, err, temps[i:], renamed)
+		}
+		renamed = append(renamed, TransactionalUpload{Local: temps[i], Remote: remote})
+	}
+
+	if c.markerNamer != nil {
+		var markers []string
+		for _, remote := range remotes {
+			name := c.markerNamer(remote)
+			if name == "" {
+				continue
+			}
+			// a marker left behind by a failed upload would announce the files
+			markers = append(markers, name)
+			if err := c.writeMarker(remote); err != nil {
+				return c.rollbackTransaction(remote, err, markers, renamed)
+			}
+		}
+	}
+	return nil
+}
+
+// rollbackTransaction moves published files back to their temporary names and
+// deletes all temporary files with DELE, bypassing the trash directory.
+func (c *FtpServerConn) rollbackTransaction(remote string, cause error, temps []string, renamed []TransactionalUpload) error {
+	txErr := &TransactionError{Remote: remote, Err: cause}
+	for _, r := range renamed {
+		if err := c.Rename(r.Remote, r.Local); err != nil {
+			txErr.RollbackErrs = append(txErr.RollbackErrs, err)
+			continue
+		}
+		temps = append(temps, r.Local)
+	}
+
+	for _, temp := range temps {
+		if err := c.dele(temp); err != nil {
+			txErr.RollbackErrs = append(txErr.RollbackErrs, err)
+		}
+	}
+	return txErr
+}
@@ -0,0 +1,88 @@
+package ftpclient
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransactionalRename(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Fail      string // command answered with 553
+		Size      string // file whose SIZE is wrong once uploaded
+		Published bool
+	}{
+		{"published", "", "", true},
+		{"upload failure", "STOR b.txt.tmp", "", false},
+		{"size mismatch", "", "b.txt.tmp", false},
+		{"rename failure", "RNTO b.txt", "", false},
+		{"marker failure", "STOR b.txt.done", "", false},
+	}
+
+	dir := t.TempDir()
+	uploads := []TransactionalUpload{
+		{Local: filepath.Join(dir, "a.txt"), Remote: "a.txt"},
+		{Local: filepath.Join(dir, "b.txt"), Remote: "b.txt"},
+	}
+	for _, u := range uploads {
+		if err := os.WriteFile(u.Local, []byte("content of "+u.Remote), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var fs *memFS
+			addr, fs := memServer(t, nil, func(s *testSession, cmd string) bool {
+				switch cmd {
+				case tc.Fail:
+					s.reply("553 not allowed")
+				case "SIZE " + tc.Size:
+					if _, ok := fs.file(tc.Size); !ok {
+						return false
+					}
+					s.reply("213 1")
+				default:
+					return false
+				}
+				return true
+			})
+			// rolled back files must be deleted, not moved to the trash
+			c := dialTestServer(t, addr, NewConfig().WithMarker(SuffixMarker(".done")).WithTrash("/trash"))
+
+			err := c.WithTransactionalRename(uploads)
+			if tc.Published {
+				if err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				var txErr *TransactionError
+				if !errors.As(err, &txErr) || txErr.Remote != "b.txt" {
+					t.Fatalf("err = %v, want a TransactionError at b.txt", err)
+				}
+			}
+
+			for _, name := range []string{"a.txt", "b.txt", "a.txt.done", "b.txt.done"} {
+				if _, ok := fs.file(name); ok != tc.Published {
+					t.Errorf("%s exists = %v, want %v", name, ok, tc.Published)
+				}
+			}
+			for _, name := range []string{"a.txt.tmp", "b.txt.tmp"} {
+				if _, ok := fs.file(name); ok {
+					t.Errorf("%s was left behind", name)
+				}
+			}
+			if cmds := fs.commands("SIZE "); !tc.Published && len(cmds) == 0 {
+				t.Error("uploads were not verified")
+			}
+			for _, cmd := range fs.commands("RNTO ") {
+				if strings.HasPrefix(cmd, "RNTO /trash") {
+					t.Errorf("rollback moved a file to the trash: %s", cmd)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package ftpclient
+
+import (
+	"context"
+	"time"
+)
+
+// transferLogInterval is the minimum time between two progress lines of a transfer log.
+const transferLogInterval = 5 * time.Second
+
+type transferLogKey struct{}
+
+// transferLog routes the log lines of one transfer to a logger under its correlation ID.
+type transferLog struct {
+	id     string
+	logger Logger
+	bytes  int64
+}
+
+// WithTransferLog returns a copy of ctx attaching the correlation ID id and logger to
+// the file transfers it is passed to, such as RetrFileContext and StorFileContext.
+// Their start, commands, progress, retries and completion are then logged to logger,
+// each line prefixed with id. A nil logger uses the logger of the connection.
+func WithTransferLog(ctx context.Context, id string, logger Logger) context.Context {
+	return context.WithValue(ctx, transferLogKey{}, &transferLog{id: id, logger: logger})
+}
+
+// TransferID returns the correlation ID attached to ctx by WithTransferLog.
+func TransferID(ctx context.Context) (string, bool) {
+	t, ok := ctx.Value(transferLogKey{}).(*transferLog)
+	if !ok {
+		return "", false
+	}
+	return t.id, true
+}
+
+// transferContext runs the transfer fn while watching ctx, logging it under the
+// transfer log attached to ctx if any. op, src and dst describe the transfer.
+func (c *FtpServerConn) transferContext(ctx context.Context, op, src, dst string, fn func() error) error {
+	attached, ok := ctx.Value(transferLogKey{}).(*transferLog)
+	if !ok || c.currentTransferLog() != nil {
+		return c.withContext(ctx, fn)
+	}
+
+	t := &transferLog{id: attached.id, logger: attached.logger}
+	if t.logger == nil {
+		t.logger = c.logger
+	}
+	c.setTransferLog(t)
+	defer c.setTransferLog(nil)
+
+	start := time.Now()
+	c.infof("%s %s -> %s started", op, src, dst)
+	err := c.withContext(ctx, fn)
+	if err != nil {
+		c.errorf("%s %s -> %s failed after %v: %v", op, src, dst, time.Since(start), err)
+		return err
+	}
+	c.infof("%s %s -> %s completed: %d bytes in %v", op, src, dst, t.bytes, time.Since(start))
+	return nil
+}
+
+func (c *FtpServerConn) currentTransferLog() *transferLog {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	return c.transferLog
+}
+
+func (c *FtpServerConn) setTransferLog(t *transferLog) {
+	c.ctxMu.Lock()
+	defer c.ctxMu.Unlock()
+	c.transferLog = t
+}
+
+// logTransferProgress writes a progress line to the transfer log, at most every
+// transferLogInterval.
+func (d *FtpDataConn) logTransferProgress() {
+	if d.c.currentTransferLog() == nil {
+		return
+	}
+	now := time.Now()
+	if d.logged.IsZero() {
+		d.logged = now
+		return
+	}
+	if now.Sub(d.logged) < transferLogInterval {
+		return
+	}
+	d.logged = now
+	if d.total >= 0 {
+		d.c.infof("progress: %d of %d bytes", d.n, d.total)
+	} else {
+		d.c.infof("progress: %d bytes", d.n)
+	}
+}
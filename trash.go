@@ -0,0 +1,80 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+)
+
+// trashStampLayout prefixes the names of trashed entries with the time they were trashed.
+const trashStampLayout = "20060102T150405.000000000"
+
+// trashName returns the name under which name is moved into the trash at t.
+func trashName(trash, name string, t time.Time) string {
+	return path.Join(trash, t.UTC().Format(trashStampLayout)+"_"+path.Base(name))
+}
+
+// trashedAt returns the time a trashed entry was moved into the trash.
+func trashedAt(name string) (time.Time, bool) {
+	stamp, _, ok := strings.Cut(name, "_")
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(trashStampLayout, stamp)
+	return t, err == nil
+}
+
+// trashes reports whether name is moved into the trash rather than removed. Entries
+// already in the trash are removed.
+func (c *FtpServerConn) trashes(name string) bool {
+	if c.trashDir == "" {
+		return false
+	}
+	trash := path.Clean(c.trashDir)
+	return !strings.HasPrefix(path.Clean(name)+"/", trash+"/")
+}
+
+// moveToTrash renames name into the configured trash directory, creating it first.
+func (c *FtpServerConn) moveToTrash(name string) error {
+	if !c.trashReady {
+		if err := c.mkdir(c.trashDir); err != nil {
+			return err
+		}
+		c.trashReady = true
+	}
+	return c.Rename(name, trashName(c.trashDir, name, time.Now()))
+}
+
+// PurgeTrash permanently removes the entries of the trash directory that were trashed
+// more than olderThan ago. Entries not named by Delete or RemoveAll are left alone.
+func (c *FtpServerConn) PurgeTrash(olderThan time.Duration) error {
+	if c.trashDir == "" {
+		return errors.New("purge trash: no trash directory configured")
+	}
+
+	infos, err := c.readDir(c.trashDir)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, info := range infos {
+		trashed, ok := trashedAt(info.Name())
+		if !ok || time.Since(trashed) < olderThan {
+			continue
+		}
+		if err := c.removeAll(path.Join(c.trashDir, info.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PurgeTrashContext is like PurgeTrash but honors ctx.
+func (c *FtpServerConn) PurgeTrashContext(ctx context.Context, olderThan time.Duration) error {
+	return c.withContext(ctx, func() error {
+		return c.PurgeTrash(olderThan)
+	})
+}
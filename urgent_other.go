@@ -0,0 +1,13 @@
+//go:build !unix
+
+package ftpclient
+
+import (
+	"net"
+)
+
+// writeUrgent writes p as ordinary data, as urgent data is not supported here.
+func writeUrgent(conn net.Conn, p []byte) error {
+	_, err := conn.Write(p)
+	return err
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package ftpclient
+
+import (
+	"net"
+	"syscall"
+)
+
+// writeUrgent writes p as TCP urgent data when conn is a TCP connection, and as
+// ordinary data otherwise.
+func writeUrgent(conn net.Conn, p []byte) error {
+	tcpConn, ok := conn.(*net.TCPConn)
+	if !ok {
+		_, err := conn.Write(p)
+		return err
+	}
+	raw, err := tcpConn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	// a short send marks its own last byte urgent; the rest is sent again with
+	// MSG_OOB so that the urgent mark ends up on the last byte of p
+	var sendErr error
+	err = raw.Write(func(fd uintptr) bool {
+		for len(p) > 0 {
+			var n int
+			n, sendErr = syscall.SendmsgN(int(fd), p, nil, nil, syscall.MSG_OOB)
+			if sendErr == syscall.EAGAIN {
+				return false
+			}
+			if sendErr != nil {
+				return true
+			}
+			p = p[n:]
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
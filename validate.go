@@ -0,0 +1,86 @@
+package ftpclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// ErrInvalidConfig is wrapped by the errors returned by Config.Validate.
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validate reports contradictory or out of range settings, joining one error per
+// problem. It is called by Dial, so that such settings fail early instead of
+// obscurely during a transfer.
+func (c *Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: %s", ErrInvalidConfig, fmt.Sprintf(format, args...)))
+	}
+
+	if c.tlsImplicit && c.tlsConfig == nil {
+		invalid("implicit TLS requires a tls.Config")
+	}
+	if c.tlsSessionBinding && c.tlsConfig == nil {
+		invalid("TLS session binding requires a tls.Config")
+	}
+	if c.protLevel != "" && !c.protLevel.Valid() {
+		invalid("unsupported protection level %q", c.protLevel)
+	}
+	if c.protLevel != "" && c.tlsConfig == nil {
+		invalid("protection level %q requires a tls.Config", c.protLevel)
+	}
+	if c.tlsSessionBinding && c.protLevel == ProtClear {
+		invalid("TLS session binding requires protected data connections")
+	}
+	if c.localAddr != "" && c.dialer != nil {
+		invalid("a local address cannot be bound by a custom dialer")
+	}
+	if c.localAddr != "" {
+		if _, err := c.localIP(); err != nil {
+			invalid("local address %q: %v", c.localAddr, err)
+		}
+	}
+	if c.externalIP != "" && net.ParseIP(c.externalIP) == nil {
+		invalid("external address %q is not an IP address", c.externalIP)
+	}
+	if c.activePortMin != 0 || c.activePortMax != 0 {
+		if c.activePortMin < 1 || c.activePortMax > 65535 || c.activePortMin > c.activePortMax {
+			invalid("invalid active port range %d-%d", c.activePortMin, c.activePortMax)
+		}
+		if c.passive || c.epsvAll {
+			invalid("an active port range is never used with passive mode forced")
+		}
+	}
+	if c.readWriteTimeout < 0 {
+		invalid("negative read/write timeout %v", c.readWriteTimeout)
+	}
+	if c.connectTimeout < 0 || c.commandTimeout < 0 || c.dataTimeout < 0 || c.acceptTimeout < 0 {
+		invalid("negative connect, command, data or accept timeout")
+	}
+	if c.keepAlive < 0 {
+		invalid("negative keepalive period %v", c.keepAlive)
+	}
+	if c.keepAlive > 0 && c.readWriteTimeout == 0 {
+		invalid("keepalive requires a read/write timeout, or a lost NOOP reply blocks forever")
+	}
+	if c.keepAliveTransfers && c.keepAlive == 0 {
+		invalid("keepalive during transfers requires a keepalive period")
+	}
+	if c.bufferSize <= 0 {
+		invalid("buffer size %d is not positive", c.bufferSize)
+	}
+	if c.stallPeriod < 0 || c.stallMinBytes < 0 {
+		invalid("negative stall watchdog period %v or minimum %d", c.stallPeriod, c.stallMinBytes)
+	}
+	if c.transferDeadline < 0 {
+		invalid("negative transfer deadline %v", c.transferDeadline)
+	}
+	if c.smallFileThreshold < 0 {
+		invalid("negative small file threshold %d", c.smallFileThreshold)
+	}
+	if c.rfc2640 && c.charset != nil && c.charset.Encoding != nil {
+		invalid("RFC 2640 requires UTF-8, not %s", c.charset.Name)
+	}
+	return errors.Join(errs...)
+}
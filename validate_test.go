@@ -0,0 +1,37 @@
+package ftpclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConfigValidate(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config *Config
+		Valid  bool
+	}{
+		{"default", NewConfig(), true},
+		{"implicit TLS", NewConfig().WithTLSImplicit(true), false},
+		{"implicit TLS with config", NewConfig().WithTLSImplicit(true).WithTLSConfig(&tls.Config{}), true},
+		{"keepalive without timeout", NewConfig().WithReadWriteTimeout(0).WithKeepAlive(time.Minute, false), false},
+		{"active port range", NewConfig().WithActivePortRange(50000, 50100), true},
+		{"single active port", NewConfig().WithActivePortRange(50000, 50000), true},
+		{"reversed active port range", NewConfig().WithActivePortRange(50100, 50000), false},
+		{"active port out of range", NewConfig().WithActivePortRange(0, 70000), false},
+		{"active port range with passive", NewConfig().WithActivePortRange(50000, 50100).WithPassive(true), false},
+		{"active port range with EPSV ALL", NewConfig().WithActivePortRange(50000, 50100).WithEpsvAll(true), false},
+	}
+
+	for _, tc := range cases {
+		err := tc.Config.Validate()
+		if tc.Valid && err != nil {
+			t.Errorf("%s: %v", tc.Name, err)
+		}
+		if !tc.Valid && !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: err = %v, want ErrInvalidConfig", tc.Name, err)
+		}
+	}
+}
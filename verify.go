@@ -0,0 +1,87 @@
+package ftpclient
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// VerificationError is returned by StorFileVerified when the stored file does not
+// match the local one. Check is "SIZE" or the checksum algorithm that detected the
+// mismatch; Local and Remote are the values compared.
+type VerificationError struct {
+	Path   string
+	Check  string
+	Local  string
+	Remote string
+}
+
+func (e *VerificationError) Error() string {
+	return fmt.Sprintf("upload verification failed for %q: remote %s %s, local %s", e.Path, e.Check, e.Remote, e.Local)
+}
+
+// StorFileVerified is like StorFile, but checks the stored file before delivering it.
+// The remote SIZE must match the local size, and when the server can compute a
+// checksum with HASH or an X command the digests must match as well. Checks the
+// server does not implement are skipped. The transfer type should be set to binary
+// beforehand. A mismatch is reported as a *VerificationError.
+func (c *FtpServerConn) StorFileVerified(local, remote string) error {
+	remote = c.mapName(remote)
+	if err := c.storFileRetry(local, remote); err != nil {
+		return err
+	}
+	if err := c.verifyStored(local, remote); err != nil {
+		return err
+	}
+	return c.deliver(local, remote)
+}
+
+// verifyStored compares the size and, when available, the checksum of remote with local.
+func (c *FtpServerConn) verifyStored(local, remote string) error {
+	n, err := c.Size(remote)
+	if err != nil && !isNotImplemented(err) {
+		return err
+	}
+	if err == nil {
+		if size := localSize(local); int64(n) != size {
+			return &VerificationError{Path: remote, Check: "SIZE", Local: fmt.Sprint(size), Remote: fmt.Sprint(n)}
+		}
+	}
+
+	result, err := c.Checksum(remote, "")
+	var unsupported *UnsupportedError
+	if errors.As(err, &unsupported) || isNotImplemented(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	digest, err := localDigest(local, result.Algorithm)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(result.Digest, digest) {
+		return &VerificationError{Path: remote, Check: result.Algorithm, Local: digest, Remote: result.Digest}
+	}
+	return nil
+}
+
+// localDigest returns the hex digest of the local file computed with algo.
+func localDigest(local, algo string) (string, error) {
+	h, err := newHash(algo)
+	if err != nil {
+		return "", err
+	}
+	file, err := os.Open(local)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err = io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,138 @@
+package ftpclient
+
+import (
+	"net/textproto"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/tsujimic/ftpclient-go/replycode"
+)
+
+// WalkFunc is the type of the function called by Walk for each file or directory.
+// It follows the conventions of filepath.WalkFunc, including filepath.SkipDir.
+type WalkFunc func(path string, info os.FileInfo, err error) error
+
+// Walk walks the remote file tree rooted at root, calling fn for each file or
+// directory in the tree, including root, in lexical order. Directories are listed
+// with MLSD when the server supports it and with LIST otherwise.
+// Symbolic links are not followed unless WithFollowSymlinks is set; they are then
+// reported as what they point at, see ResolveSymlink, and every directory reached
+// through links is walked once.
+func (c *FtpServerConn) Walk(root string, fn WalkFunc) error {
+	var visited map[string]bool
+	if c.followSymlinks {
+		visited = make(map[string]bool)
+		if real, err := c.realDir(root); err == nil {
+			visited[real] = true
+		}
+	}
+
+	info := &fileInfo{name: path.Base(root), mode: os.ModeDir}
+	err := c.walk(root, info, fn, visited)
+	if err == filepath.SkipDir {
+		return nil
+	}
+	return err
+}
+
+// walk walks dir; visited holds the real paths of the directories walked when
+// following symbolic links, and is nil otherwise.
+func (c *FtpServerConn) walk(dir string, info os.FileInfo, fn WalkFunc, visited map[string]bool) error {
+	if err := fn(dir, info, nil); err != nil {
+		return err
+	}
+
+	infos, err := c.readDir(dir)
+	if err != nil {
+		return fn(dir, info, err)
+	}
+
+	for _, child := range infos {
+		name := path.Join(dir, child.Name())
+		if visited != nil && child.Mode()&os.ModeSymlink != 0 {
+			resolved, real, err := c.resolveSymlink(name)
+			switch {
+			case err != nil:
+				if err := fn(name, child, err); err != nil {
+					if err == filepath.SkipDir {
+						return nil
+					}
+					return err
+				}
+				continue
+			case resolved.IsDir() && visited[real]:
+				// a link back to a walked directory is reported unresolved
+			default:
+				child = resolved
+				if resolved.IsDir() {
+					visited[real] = true
+				}
+			}
+		}
+		if !child.IsDir() {
+			if err := fn(name, child, nil); err != nil {
+				if err == filepath.SkipDir {
+					return nil
+				}
+				return err
+			}
+			continue
+		}
+
+		if err := c.walk(name, child, fn, visited); err != nil && err != filepath.SkipDir {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDir lists dir and returns its entries sorted by name, without "." and "..".
+func (c *FtpServerConn) readDir(dir string) ([]os.FileInfo, error) {
+	var infos []os.FileInfo
+	if !c.mlsdUnsupported {
+		entries, err := c.Mlsd(dir)
+		if err == nil {
+			for _, e := range entries {
+				if e.Type == "cdir" || e.Type == "pdir" {
+					continue
+				}
+				infos = append(infos, e.FileInfo())
+			}
+			return sortInfos(infos), nil
+		}
+		if !isNotImplemented(err) {
+			return nil, err
+		}
+		c.mlsdUnsupported = true
+	}
+
+	all, err := c.Dir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range all {
+		if info.Name() == "." || info.Name() == ".." {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return sortInfos(infos), nil
+}
+
+func sortInfos(infos []os.FileInfo) []os.FileInfo {
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	return infos
+}
+
+// isNotImplemented reports whether err is a reply meaning that the command is not
+// implemented or not understood by the server.
+func isNotImplemented(err error) bool {
+	if e, ok := err.(*textproto.Error); ok {
+		return replycode.IsNotImplemented(e.Code)
+	}
+	return false
+}
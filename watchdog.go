@@ -0,0 +1,160 @@
+package ftpclient
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// StalledTransferError is returned when a transfer is aborted by the stall watchdog
+// because fewer than MinBytes went through the data connection during Period.
+// Bytes is the number of bytes transferred before the abort.
+type StalledTransferError struct {
+	Period   time.Duration
+	MinBytes int64
+	Bytes    int64
+}
+
+func (e *StalledTransferError) Error() string {
+	return fmt.Sprintf("transfer stalled: less than %d bytes in %v after %d bytes", e.MinBytes, e.Period, e.Bytes)
+}
+
+// TransferDeadlineError is returned when a transfer is aborted because it lasted
+// longer than the configured transfer deadline. Bytes is the number of bytes
+// transferred before the abort.
+type TransferDeadlineError struct {
+	Deadline time.Duration
+	Bytes    int64
+}
+
+func (e *TransferDeadlineError) Error() string {
+	return fmt.Sprintf("transfer exceeded its %v deadline after %d bytes", e.Deadline, e.Bytes)
+}
+
+// Timeout reports that the error is a timeout.
+func (e *TransferDeadlineError) Timeout() bool {
+	return true
+}
+
+// Watchdog states.
+const (
+	watchRunning int32 = iota
+	watchStalled
+	watchExpired
+)
+
+// stallWatchdog interrupts a data connection once a period passes with too little
+// progress, even though each Read or Write still meets its deadline, or once the
+// transfer deadline passes.
+type stallWatchdog struct {
+	conn     net.Conn
+	period   time.Duration
+	minBytes int64
+	deadline time.Duration
+	bytes    int64 // atomic, since the last tick
+	stalled  int32 // atomic, a watch state
+	done     chan struct{}
+}
+
+func startStallWatchdog(conn net.Conn, period time.Duration, minBytes int64, deadline time.Duration) *stallWatchdog {
+	w := &stallWatchdog{
+		conn:     conn,
+		period:   period,
+		minBytes: minBytes,
+		deadline: deadline,
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *stallWatchdog) run() {
+	var tick, expire <-chan time.Time
+	if w.period > 0 {
+		ticker := time.NewTicker(w.period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	if w.deadline > 0 {
+		timer := time.NewTimer(w.deadline)
+		defer timer.Stop()
+		expire = timer.C
+	}
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-tick:
+			if atomic.SwapInt64(&w.bytes, 0) < w.minBytes {
+				w.interrupt(watchStalled)
+				return
+			}
+		case <-expire:
+			w.interrupt(watchExpired)
+			return
+		}
+	}
+}
+
+func (w *stallWatchdog) interrupt(state int32) {
+	// set the state before the deadline, Read and Write check it after theirs
+	atomic.StoreInt32(&w.stalled, state)
+	w.conn.SetDeadline(aLongTimeAgo)
+}
+
+func (w *stallWatchdog) add(n int) {
+	atomic.AddInt64(&w.bytes, int64(n))
+}
+
+func (w *stallWatchdog) isStalled() bool {
+	return atomic.LoadInt32(&w.stalled) != watchRunning
+}
+
+func (w *stallWatchdog) stop() {
+	select {
+	case <-w.done:
+	default:
+		close(w.done)
+	}
+}
+
+// newDataConn wraps a data connection, watching it for stalls and for the transfer
+// deadline when configured.
+func (c *FtpServerConn) newDataConn(conn net.Conn) *FtpDataConn {
+	d := &FtpDataConn{conn: conn, c: c, total: c.announcedSize()}
+	if c.stallPeriod > 0 || c.transferDeadline > 0 {
+		d.watchdog = startStallWatchdog(conn, c.stallPeriod, c.stallMinBytes, c.transferDeadline)
+	}
+	d.observeOpen()
+	return d
+}
+
+// stalled reports whether the watchdog interrupted d, for a stall or the deadline.
+func (d *FtpDataConn) stalled() bool {
+	return d.watchdog != nil && d.watchdog.isStalled()
+}
+
+func (d *FtpDataConn) stallError() error {
+	if atomic.LoadInt32(&d.watchdog.stalled) == watchExpired {
+		return &TransferDeadlineError{Deadline: d.watchdog.deadline, Bytes: d.n}
+	}
+	return &StalledTransferError{Period: d.watchdog.period, MinBytes: d.watchdog.minBytes, Bytes: d.n}
+}
+
+// abort closes a transfer whose completion reply has not been read with ABOR. The
+// server answers the transfer command first, with 426 when the transfer was
+// interrupted or 226 when it had already completed, and then acknowledges ABOR with
+// 225 or 226, so both replies are read.
+func (d *FtpDataConn) abort() error {
+	d.replied = true
+	d.conn.Close()
+	if err := d.c.putAbort(); err != nil {
+		return err
+	}
+	if _, _, err := d.c.getResponse(-1); err != nil {
+		return err
+	}
+	_, _, err := d.c.getResponse(-1)
+	return err
+}
@@ -0,0 +1,126 @@
+package ftpclient
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataConnAbortReplies(t *testing.T) {
+	cases := []struct {
+		Name      string
+		Completed bool
+		Abort     []string
+	}{
+		// the transfer completed before ABOR arrived
+		{"completed", true, []string{"226 abort successful"}},
+		{"completed 225", true, []string{"225 no transfer to abort"}},
+		// the transfer was interrupted by ABOR
+		{"interrupted", false, []string{"426 transfer aborted", "226 abort successful"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var data net.Conn
+			addr := testServer(t, func(s *testSession, cmd string) bool {
+				switch {
+				case strings.HasPrefix(cmd, "RETR "):
+					s.reply("150 opening")
+					conn, err := s.accept()
+					if err != nil {
+						return true
+					}
+					conn.Write([]byte("data"))
+					if tc.Completed {
+						conn.Close()
+						s.reply("226 transfer complete")
+					} else {
+						data = conn
+					}
+				case strings.HasSuffix(cmd, "ABOR"):
+					// ABOR follows the Telnet IP and Synch sequences
+					if data != nil {
+						data.Close()
+					}
+					for _, reply := range tc.Abort {
+						s.reply(reply)
+					}
+				case cmd == "NOOP":
+					s.reply("200 noop")
+				default:
+					return false
+				}
+				return true
+			})
+			c := dialTestServer(t, addr, NewConfig())
+
+			r, err := c.RetrRequest("file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := make([]byte, 4)
+			if _, err = io.ReadFull(r, buf); err != nil {
+				t.Fatal(err)
+			}
+			if err = r.(*FtpDataConn).Abort(); err != nil {
+				t.Fatal(err)
+			}
+
+			// the ABOR reply must not be taken for the reply to the next command
+			if err = c.Noop(); err != nil {
+				t.Fatal(err)
+			}
+			if code, msg := c.LastResponse(); code != 200 || msg != "noop" {
+				t.Errorf("last response = %d %s, want 200 noop", code, msg)
+			}
+		})
+	}
+}
+
+func TestStallWatchdog(t *testing.T) {
+	var data net.Conn
+	addr, _ := memServer(t, nil, func(s *testSession, cmd string) bool {
+		switch {
+		case strings.HasPrefix(cmd, "RETR "):
+			s.reply("150 opening")
+			conn, err := s.accept()
+			if err != nil {
+				return true
+			}
+			// send a little and stall with the connection open
+			conn.Write([]byte("data"))
+			data = conn
+		case strings.HasSuffix(cmd, "ABOR"):
+			if data != nil {
+				data.Close()
+			}
+			s.reply("426 transfer aborted")
+			s.reply("226 abort successful")
+		default:
+			return false
+		}
+		return true
+	})
+	c := dialTestServer(t, addr, NewConfig().WithStallWatchdog(50*time.Millisecond, 1))
+
+	start := time.Now()
+	_, err := c.RetrTo("file", io.Discard, 0)
+	var stallErr *StalledTransferError
+	if !errors.As(err, &stallErr) {
+		t.Fatalf("err = %v, want a StalledTransferError", err)
+	}
+	if stallErr.Bytes != 4 {
+		t.Errorf("bytes = %d, want 4", stallErr.Bytes)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("stall detected after %v", elapsed)
+	}
+
+	// the session stays usable after the abort
+	if err = c.Noop(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,160 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"strings"
+)
+
+// xhashCommands maps the Hash* algorithm names to their X commands.
+var xhashCommands = map[string]string{
+	HashCRC32:  "XCRC",
+	HashMD5:    "XMD5",
+	HashSHA1:   "XSHA1",
+	HashSHA256: "XSHA256",
+	HashSHA512: "XSHA512",
+}
+
+// checksumPreference orders algorithms from strongest to weakest.
+var checksumPreference = []string{HashSHA512, HashSHA256, HashSHA1, HashMD5, HashCRC32}
+
+// parseXHashReply extracts the hex digest from the reply to an X hash command, which
+// servers format as "<digest>", "<algo> <digest>" or "<digest> <path>".
+func parseXHashReply(msg string) (string, error) {
+	for _, field := range strings.Fields(msg) {
+		if len(field) >= 8 && isHex(field) {
+			return strings.ToLower(field), nil
+		}
+	}
+	return "", errors.New("Unsuported response format")
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'f' || r >= 'A' && r <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+// xhash issues the X hash command cmd for path and returns the hex digest.
+func (c *FtpServerConn) xhash(cmd, path string) (string, error) {
+	code, msg, err := c.SendCmd(-1, "%s %s", cmd, path)
+	if err != nil {
+		return "", err
+	}
+	if code < 200 || code > 299 {
+		return "", &textproto.Error{Code: code, Msg: msg}
+	}
+	return parseXHashReply(msg)
+}
+
+// XCRC issues a XCRC FTP command and returns the CRC-32 of path in hex.
+func (c *FtpServerConn) XCRC(path string) (string, error) {
+	return c.xhash("XCRC", path)
+}
+
+// XCRCContext is like XCRC but honors ctx.
+func (c *FtpServerConn) XCRCContext(ctx context.Context, path string) (digest string, err error) {
+	err = c.withContext(ctx, func() error {
+		digest, err = c.XCRC(path)
+		return err
+	})
+	return digest, err
+}
+
+// XMD5 issues a XMD5 FTP command and returns the MD5 digest of path in hex.
+func (c *FtpServerConn) XMD5(path string) (string, error) {
+	return c.xhash("XMD5", path)
+}
+
+// XMD5Context is like XMD5 but honors ctx.
+func (c *FtpServerConn) XMD5Context(ctx context.Context, path string) (digest string, err error) {
+	err = c.withContext(ctx, func() error {
+		digest, err = c.XMD5(path)
+		return err
+	})
+	return digest, err
+}
+
+// XSHA1 issues a XSHA1 FTP command and returns the SHA-1 digest of path in hex.
+func (c *FtpServerConn) XSHA1(path string) (string, error) {
+	return c.xhash("XSHA1", path)
+}
+
+// XSHA1Context is like XSHA1 but honors ctx.
+func (c *FtpServerConn) XSHA1Context(ctx context.Context, path string) (digest string, err error) {
+	err = c.withContext(ctx, func() error {
+		digest, err = c.XSHA1(path)
+		return err
+	})
+	return digest, err
+}
+
+// XSHA256 issues a XSHA256 FTP command and returns the SHA-256 digest of path in hex.
+func (c *FtpServerConn) XSHA256(path string) (string, error) {
+	return c.xhash("XSHA256", path)
+}
+
+// XSHA256Context is like XSHA256 but honors ctx.
+func (c *FtpServerConn) XSHA256Context(ctx context.Context, path string) (digest string, err error) {
+	err = c.withContext(ctx, func() error {
+		digest, err = c.XSHA256(path)
+		return err
+	})
+	return digest, err
+}
+
+// Checksum returns the digest of path computed by the server with algo, one of the
+// Hash* names, or with the strongest algorithm available when algo is empty. HASH is
+// used when FEAT lists the algorithm for it, and the matching X command otherwise.
+// An *UnsupportedError is returned when FEAT lists neither.
+func (c *FtpServerConn) Checksum(path, algo string) (*HashResult, error) {
+	features, err := c.Feat()
+	if err != nil && !isNotImplemented(err) {
+		return nil, err
+	}
+
+	algos := checksumPreference
+	if algo != "" {
+		algos = []string{strings.ToUpper(algo)}
+	}
+	hashAlgos, current := features.HashAlgorithms()
+	for _, a := range algos {
+		for _, h := range hashAlgos {
+			if !strings.EqualFold(h, a) {
+				continue
+			}
+			if !strings.EqualFold(current, a) {
+				if err := c.SetHashAlgorithm(h); err != nil {
+					return nil, err
+				}
+			}
+			return c.Hash(path)
+		}
+		if cmd, ok := xhashCommands[a]; ok && features.Has(cmd) {
+			digest, err := c.xhash(cmd, path)
+			if err != nil {
+				return nil, err
+			}
+			return &HashResult{Algorithm: a, Start: -1, End: -1, Digest: digest, Path: path}, nil
+		}
+	}
+
+	name := "HASH"
+	if algo != "" {
+		name += " " + algo
+	}
+	return nil, &UnsupportedError{Command: name}
+}
+
+// ChecksumContext is like Checksum but honors ctx.
+func (c *FtpServerConn) ChecksumContext(ctx context.Context, path, algo string) (result *HashResult, err error) {
+	err = c.withContext(ctx, func() error {
+		result, err = c.Checksum(path, algo)
+		return err
+	})
+	return result, err
+}
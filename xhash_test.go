@@ -0,0 +1,47 @@
+package ftpclient
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseXHashReply(t *testing.T) {
+	// go test -v -run TestParseXHashReply
+	for _, msg := range []string{"D41D8CD98F00B204E9800998ECF8427E", "MD5 d41d8cd98f00b204e9800998ecf8427e", "d41d8cd98f00b204e9800998ecf8427e /pub/empty"} {
+		digest, err := parseXHashReply(msg)
+		if err != nil || digest != "d41d8cd98f00b204e9800998ecf8427e" {
+			t.Errorf("parseXHashReply(%q) = %q, %v", msg, digest, err)
+		}
+	}
+}
+
+func TestChecksumContext(t *testing.T) {
+	// go test -v -run TestChecksumContext
+	addr := testServer(t, func(s *testSession, cmd string) bool {
+		switch cmd {
+		case "FEAT":
+			s.reply("211-Features:\r\n XMD5\r\n211 End")
+		case "XMD5 empty":
+			s.reply("250 d41d8cd98f00b204e9800998ecf8427e")
+		case "XCRC slow":
+			// never answer
+		default:
+			return false
+		}
+		return true
+	})
+	c := dialTestServer(t, addr, NewConfig())
+
+	result, err := c.ChecksumContext(context.Background(), "empty", "")
+	if err != nil || result.Algorithm != HashMD5 || result.Digest != "d41d8cd98f00b204e9800998ecf8427e" {
+		t.Errorf("ChecksumContext() = %+v, %v", result, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err = c.XCRCContext(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("XCRCContext() = %v, want context.DeadlineExceeded", err)
+	}
+}